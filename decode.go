@@ -0,0 +1,81 @@
+package oojson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// An orderedObject is a JSON object decoded with the order of its keys.
+type orderedObject struct {
+	keys   []string
+	values map[string]any
+}
+
+// decodeValue decodes the next JSON value of decoder token by token. Objects
+// are decoded as orderedObjects, and the rest as by decoder.Decode.
+func decodeValue(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	return decodeTokenValue(decoder, token)
+}
+
+// decodeTokenValue decodes the JSON value that starts with token.
+func decodeTokenValue(decoder *json.Decoder, token json.Token) (any, error) {
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+	switch delim {
+	case '{':
+		object := orderedObject{values: make(map[string]any)}
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, nestedError(err)
+			}
+			key, ok := token.(string)
+			if !ok {
+				return nil, fmt.Errorf("oojson: unexpected %v in object", token)
+			}
+			value, err := decodeValue(decoder)
+			if err != nil {
+				return nil, nestedError(err)
+			}
+			if _, ok := object.values[key]; !ok {
+				object.keys = append(object.keys, key)
+			}
+			object.values[key] = value
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, nestedError(err)
+		}
+		return object, nil
+	case '[':
+		array := []any{}
+		for decoder.More() {
+			value, err := decodeValue(decoder)
+			if err != nil {
+				return nil, nestedError(err)
+			}
+			array = append(array, value)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, nestedError(err)
+		}
+		return array, nil
+	default:
+		return nil, fmt.Errorf("oojson: unexpected %v", delim)
+	}
+}
+
+// nestedError returns err, or io.ErrUnexpectedEOF if the input ended inside a
+// value.
+func nestedError(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
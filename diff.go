@@ -0,0 +1,431 @@
+package oojson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/maps"
+)
+
+// A ChangeKind is the kind of a Change.
+type ChangeKind int
+
+// Change kinds.
+const (
+	ChangeAdded        ChangeKind = iota // the property is new
+	ChangeRemoved                        // the property is gone
+	ChangeRetyped                        // the JSON type of the value changed
+	ChangeOptional                       // the property was always present and is now sometimes absent
+	ChangeRequired                       // the property was sometimes absent and is now always present
+	ChangeNullable                       // the value was never null and is now sometimes null
+	ChangeNotNullable                    // the value was sometimes null and is now never null
+	ChangeEnumNarrowed                   // some values of the enum are gone
+	ChangeEnumWidened                    // the enum has new values
+)
+
+// changeKindNames are the descriptions of the change kinds in text reports.
+var changeKindNames = [...]string{
+	ChangeAdded:        "added",
+	ChangeRemoved:      "removed",
+	ChangeRetyped:      "retyped",
+	ChangeOptional:     "became optional",
+	ChangeRequired:     "became required",
+	ChangeNullable:     "became nullable",
+	ChangeNotNullable:  "became non-nullable",
+	ChangeEnumNarrowed: "enum narrowed",
+	ChangeEnumWidened:  "enum widened",
+}
+
+// changeKindIDs are the stable identifiers of the change kinds in JSON
+// reports.
+var changeKindIDs = [...]string{
+	ChangeAdded:        "added",
+	ChangeRemoved:      "removed",
+	ChangeRetyped:      "retyped",
+	ChangeOptional:     "optional",
+	ChangeRequired:     "required",
+	ChangeNullable:     "nullable",
+	ChangeNotNullable:  "not_nullable",
+	ChangeEnumNarrowed: "enum_narrowed",
+	ChangeEnumWidened:  "enum_widened",
+}
+
+// String returns the description of k, e.g. "became nullable".
+func (k ChangeKind) String() string {
+	if k < 0 || int(k) >= len(changeKindNames) {
+		return "ChangeKind(" + strconv.Itoa(int(k)) + ")"
+	}
+	return changeKindNames[k]
+}
+
+// MarshalText encodes k as its identifier, e.g. nullable, which unlike its
+// description does not change between versions.
+func (k ChangeKind) MarshalText() ([]byte, error) {
+	if k < 0 || int(k) >= len(changeKindIDs) {
+		return nil, fmt.Errorf("oojson: invalid change kind %d", k)
+	}
+	return []byte(changeKindIDs[k]), nil
+}
+
+// UnmarshalText decodes the identifier of a change kind.
+func (k *ChangeKind) UnmarshalText(text []byte) error {
+	for kind, id := range changeKindIDs {
+		if id == string(text) {
+			*k = ChangeKind(kind)
+			return nil
+		}
+	}
+	return fmt.Errorf("oojson: unknown change kind %q", text)
+}
+
+// A Change is a difference between two Values. Breaking changes are those
+// after which code written against the types generated from the old Value can
+// fail to decode the new data, or fail to compile against the regenerated
+// types.
+type Change struct {
+	Path     string     `json:"path"` // JSON path of the value, in the syntax of WithOverride selectors
+	Kind     ChangeKind `json:"kind"`
+	Old      string     `json:"old,omitempty"` // old type, presence or enum values
+	New      string     `json:"new,omitempty"` // new type, presence or enum values
+	Breaking bool       `json:"breaking"`
+}
+
+// A DiffReport lists the changes between two Values.
+type DiffReport struct {
+	Breaking bool     `json:"breaking"` // whether any change is breaking
+	Changes  []Change `json:"changes"`
+}
+
+// A Differ holds the settings of a comparison. They default to those of the
+// generators, and should be set like the options of the generators so that
+// the report describes the generated types.
+type Differ struct {
+	// MaxEnumValues is the number of distinct values up to which values are
+	// compared as enums, as with WithEnums. Zero, as without WithEnums,
+	// disables the comparison of enums.
+	MaxEnumValues int
+
+	// MinDynamicKeys is the number of keys from which objects are compared as
+	// maps, as with WithDynamicKeys. Zero disables dynamic keys.
+	MinDynamicKeys int
+
+	// Tuples compares fixed-length arrays index by index, as with
+	// WithTuples(true).
+	Tuples bool
+
+	// Unions compares the objects of discriminated unions variant by
+	// variant, as with WithUnions(true).
+	Unions bool
+}
+
+// NewDiffer returns a Differ with the default settings.
+func NewDiffer() *Differ {
+	return &Differ{}
+}
+
+// Diff returns the changes from old to new with the default settings.
+func Diff(old, new *Value) *DiffReport {
+	return NewDiffer().Diff(old, new)
+}
+
+// Diff returns the changes from old to new, ordered by path. Properties are
+// compared recursively, array elements at path[], the indexes of tuples at
+// path[i], the values of maps at path[*] and the variants of unions at
+// path[?(@.discriminator=="value")]. Values are compared as enums if both
+// would be enums with WithEnums(d.MaxEnumValues).
+func (d *Differ) Diff(old, new *Value) *DiffReport {
+	c := &comparison{
+		options: commonOption{
+			maxEnumValues:  d.MaxEnumValues,
+			minDynamicKeys: d.MinDynamicKeys,
+			tuples:         d.Tuples,
+			unions:         d.Unions,
+		},
+		report: &DiffReport{Changes: []Change{}},
+	}
+	c.diff("$", emptyIfNil(old), emptyIfNil(new))
+	return c.report
+}
+
+// String returns the report as text, one change per line followed by a
+// summary.
+func (r *DiffReport) String() string {
+	var b strings.Builder
+	breaking := 0
+	for _, c := range r.Changes {
+		severity := "compatible"
+		if c.Breaking {
+			severity = "breaking"
+			breaking++
+		}
+		fmt.Fprintf(&b, "%-10s %s: %s", severity, c.Path, c.Kind)
+		switch {
+		case c.Old != "" && c.New != "":
+			fmt.Fprintf(&b, " %s -> %s", c.Old, c.New)
+		case c.Old != "":
+			fmt.Fprintf(&b, " %s", c.Old)
+		case c.New != "":
+			fmt.Fprintf(&b, " %s", c.New)
+		}
+		b.WriteByte('\n')
+	}
+	fmt.Fprintf(&b, "%d changes, %d breaking\n", len(r.Changes), breaking)
+	return b.String()
+}
+
+// WriteText writes the report to w as by String.
+func (r *DiffReport) WriteText(w io.Writer) error {
+	_, err := io.WriteString(w, r.String())
+	return err
+}
+
+// WriteJSON writes the report to w as indented JSON.
+func (r *DiffReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// A comparison collects the changes between two Values.
+type comparison struct {
+	options commonOption
+	report  *DiffReport
+}
+
+// add adds a change to the report.
+func (c *comparison) add(path string, kind ChangeKind, old, new string, breaking bool) {
+	c.report.Changes = append(c.report.Changes, Change{Path: path, Kind: kind, Old: old, New: new, Breaking: breaking})
+	c.report.Breaking = c.report.Breaking || breaking
+}
+
+// diff adds the changes from old to new, which are at path, and their
+// descendants.
+func (c *comparison) diff(path string, old, new *Value) {
+	oldKinds, newKinds := jsonKinds(old), jsonKinds(new)
+	if oldKinds != newKinds {
+		c.add(path, ChangeRetyped, describeKinds(oldKinds), describeKinds(newKinds), !acceptsKinds(oldKinds, newKinds))
+	}
+	switch {
+	case old.Nulls == 0 && new.Nulls > 0:
+		c.add(path, ChangeNullable, "", "", true)
+	case old.Nulls > 0 && new.Nulls == 0:
+		c.add(path, ChangeNotNullable, "", "", false)
+	}
+	c.diffEnums(path, old, new)
+	c.diffArrays(path, old, new)
+	// The keys of maps are data, so keys that come and go are not changes.
+	if c.options.keyKind(old) != keysStatic || c.options.keyKind(new) != keysStatic {
+		if old.AllObjectProperties != nil && new.AllObjectProperties != nil {
+			c.diff(path+"[*]", old.AllObjectProperties, new.AllObjectProperties)
+		}
+		return
+	}
+	if oldUnion, newUnion := c.options.union(old), c.options.union(new); oldUnion != nil && newUnion != nil && oldUnion.property == newUnion.property {
+		c.diffVariants(path, oldUnion, newUnion)
+		return
+	}
+	c.diffProperties(path, old, new)
+}
+
+// diffProperties adds the changes of the properties of the objects old and
+// new, which are at path.
+func (c *comparison) diffProperties(path string, old, new *Value) {
+	properties := maps.Keys(old.ObjectProperties)
+	for property := range new.ObjectProperties {
+		if _, ok := old.ObjectProperties[property]; !ok {
+			properties = append(properties, property)
+		}
+	}
+	sort.Strings(properties)
+	for _, property := range properties {
+		propertyPath := path + pathSegment(property)
+		oldProperty, inOld := old.ObjectProperties[property]
+		newProperty, inNew := new.ObjectProperties[property]
+		switch {
+		case !inOld:
+			c.add(propertyPath, ChangeAdded, "", describeKinds(jsonKinds(newProperty)), false)
+		case !inNew:
+			c.add(propertyPath, ChangeRemoved, describeKinds(jsonKinds(oldProperty)), "", true)
+		default:
+			switch {
+			case oldProperty.Absents == 0 && newProperty.Absents > 0:
+				c.add(propertyPath, ChangeOptional, "", "", true)
+			case oldProperty.Absents > 0 && newProperty.Absents == 0:
+				c.add(propertyPath, ChangeRequired, "", "", false)
+			}
+			c.diff(propertyPath, oldProperty, newProperty)
+		}
+	}
+}
+
+// diffArrays adds the changes of the elements of the arrays old and new,
+// which are at path. Tuples of the same length are compared index by index,
+// and tuples whose length changed are retyped.
+func (c *comparison) diffArrays(path string, old, new *Value) {
+	if old.ArrayElements == nil || new.ArrayElements == nil {
+		return
+	}
+	oldIndexes, _ := c.options.tuple(old)
+	newIndexes, _ := c.options.tuple(new)
+	switch {
+	case oldIndexes == nil || newIndexes == nil:
+		c.diff(path+"[]", old.ArrayElements, new.ArrayElements)
+	case len(oldIndexes) != len(newIndexes):
+		c.add(path, ChangeRetyped, describeTuple(oldIndexes), describeTuple(newIndexes), true)
+	default:
+		for i := range oldIndexes {
+			c.diff(path+"["+strconv.Itoa(i)+"]", oldIndexes[i], newIndexes[i])
+		}
+	}
+}
+
+// describeTuple returns the length of the tuple with indexes, e.g. "tuple of 2".
+func describeTuple(indexes []*Value) string {
+	return "tuple of " + strconv.Itoa(len(indexes))
+}
+
+// diffVariants adds the changes of the variants of the unions old and new,
+// which are at path and have the same discriminator. Variants are compared
+// like properties, as each is a generated type.
+func (c *comparison) diffVariants(path string, old, new *union) {
+	values := append([]string(nil), old.values...)
+	for _, value := range new.values {
+		if _, ok := old.variants[value]; !ok {
+			values = append(values, value)
+		}
+	}
+	sort.Strings(values)
+	for _, value := range values {
+		variantPath := path + "[?(@." + old.property + "==" + strconv.Quote(value) + ")]"
+		oldVariant, inOld := old.variants[value]
+		newVariant, inNew := new.variants[value]
+		switch {
+		case !inOld:
+			c.add(variantPath, ChangeAdded, "", "object", false)
+		case !inNew:
+			c.add(variantPath, ChangeRemoved, "object", "", true)
+		default:
+			c.diffProperties(variantPath, oldVariant, newVariant)
+		}
+	}
+}
+
+// diffEnums adds the values that the enum at path lost and gained. Removed
+// values are breaking, as their constants disappear from the regenerated
+// types.
+func (c *comparison) diffEnums(path string, old, new *Value) {
+	oldEnum, newEnum := c.options.enum(old), c.options.enum(new)
+	if oldEnum == nil || newEnum == nil || (oldEnum.strings == nil) != (newEnum.strings == nil) {
+		return
+	}
+	oldValues, newValues := oldEnum.values(), newEnum.values()
+	if removed := missingValues(oldValues, newValues); len(removed) > 0 {
+		c.add(path, ChangeEnumNarrowed, strings.Join(removed, " | "), "", true)
+	}
+	if added := missingValues(newValues, oldValues); len(added) > 0 {
+		c.add(path, ChangeEnumWidened, "", strings.Join(added, " | "), false)
+	}
+}
+
+// values returns the values of e as JSON literals.
+func (e *enum) values() []string {
+	values := make([]string, 0, len(e.strings)+len(e.ints))
+	for _, value := range e.strings {
+		values = append(values, strconv.Quote(value))
+	}
+	for _, value := range e.ints {
+		values = append(values, strconv.FormatInt(value, 10))
+	}
+	return values
+}
+
+// missingValues returns the values that are in values and not in others.
+func missingValues(values, others []string) []string {
+	present := make(map[string]bool, len(others))
+	for _, value := range others {
+		present[value] = true
+	}
+	var missing []string
+	for _, value := range values {
+		if !present[value] {
+			missing = append(missing, value)
+		}
+	}
+	return missing
+}
+
+// kindInteger extends the kinds of valueKinds with numbers that were all
+// integers, which the generated types distinguish from other numbers.
+const kindInteger = kindString << 1
+
+// kindNames are the JSON type names of the kinds, in the order they are
+// described.
+var kindNames = []struct {
+	kind int
+	name string
+}{
+	{kindArray, "array"},
+	{kindBool, "boolean"},
+	{kindInteger, "integer"},
+	{kindNumber, "number"},
+	{kindObject, "object"},
+	{kindString, "string"},
+}
+
+// jsonKinds returns the kinds of values observed in v, with kindInteger
+// instead of kindNumber if no number had a fraction.
+func jsonKinds(v *Value) int {
+	kinds := valueKinds(v)
+	if v.Ints > 0 && v.Float64s == 0 {
+		kinds = kinds&^kindNumber | kindInteger
+	}
+	return kinds
+}
+
+// acceptsKinds returns true if a type generated for the kinds old can decode
+// every value of the kinds new. A type generated from nulls alone accepts
+// anything, and numbers accept integers.
+func acceptsKinds(old, new int) bool {
+	if old == 0 {
+		return true
+	}
+	if old&kindNumber != 0 {
+		old |= kindInteger
+	}
+	return new&^old == 0
+}
+
+// describeKinds returns the kinds as a union of JSON type names, or "null" if
+// there are none.
+func describeKinds(kinds int) string {
+	var names []string
+	for _, k := range kindNames {
+		if kinds&k.kind != 0 {
+			names = append(names, k.name)
+		}
+	}
+	if len(names) == 0 {
+		return "null"
+	}
+	return strings.Join(names, " | ")
+}
+
+// pathSegment returns the segment of the JSON path that selects property.
+func pathSegment(property string) string {
+	if property == "" || property == "*" || strings.ContainsAny(property, ".[]\"' \t\n") {
+		return "[" + strconv.Quote(property) + "]"
+	}
+	return "." + property
+}
+
+// emptyIfNil returns v, or an empty Value if v is nil.
+func emptyIfNil(v *Value) *Value {
+	if v == nil {
+		return &Value{}
+	}
+	return v
+}
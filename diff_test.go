@@ -0,0 +1,106 @@
+package oojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// observeAll observes each of the JSON documents samples into one Value.
+func observeAll(t *testing.T, samples ...string) *Value {
+	t.Helper()
+	var v *Value
+	for _, sample := range samples {
+		var err error
+		if v, err = NewObserver().ObserveJSON(v, []byte(sample)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return v
+}
+
+func TestDiffKindIdentifiers(t *testing.T) {
+	report := Diff(observeAll(t, `{"a":1}`), observeAll(t, `{"a":1}`, `{"a":null}`))
+	b := &bytes.Buffer{}
+	if err := report.WriteJSON(b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `"kind": "nullable"`) {
+		t.Errorf("expected the identifier nullable, got\n%s", b)
+	}
+	if text := report.String(); !strings.Contains(text, "became nullable") {
+		t.Errorf("expected the description became nullable, got\n%s", text)
+	}
+
+	var decoded DiffReport
+	if err := json.Unmarshal(b.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Changes) != 1 || decoded.Changes[0].Kind != ChangeNullable {
+		t.Errorf("expected a nullable change, got %+v", decoded.Changes)
+	}
+}
+
+func TestDiffMaxEnumValues(t *testing.T) {
+	old := observeAll(t, `{"s":"a"}`, `{"s":"b"}`, `{"s":"c"}`, `{"s":"a"}`, `{"s":"b"}`, `{"s":"c"}`)
+	new := observeAll(t, `{"s":"a"}`, `{"s":"b"}`, `{"s":"a"}`, `{"s":"b"}`)
+	// Like the generators, the default Differ has no enums.
+	if report := Diff(old, new); len(report.Changes) != 0 {
+		t.Errorf("expected no changes without enums, got %+v", report.Changes)
+	}
+	if report := (&Differ{MaxEnumValues: 8}).Diff(old, new); len(report.Changes) != 1 || report.Changes[0].Kind != ChangeEnumNarrowed {
+		t.Errorf("expected the enum to narrow, got %+v", report.Changes)
+	}
+	if report := (&Differ{MaxEnumValues: 2}).Diff(old, new); len(report.Changes) != 0 {
+		t.Errorf("expected no enum with 3 values over MaxEnumValues 2, got %+v", report.Changes)
+	}
+}
+
+func TestDiffStructures(t *testing.T) {
+	tests := []struct {
+		name   string
+		differ *Differ
+		old    []string
+		new    []string
+		want   []Change
+	}{
+		{
+			name:   "dynamic keys",
+			differ: &Differ{MinDynamicKeys: 10},
+			old:    []string{`{"users":{"u_1":{"n":1},"u_2":{"n":2}}}`},
+			new:    []string{`{"users":{"u_3":{"n":3},"u_4":{"n":"x"}}}`},
+			want:   []Change{{Path: "$.users[*].n", Kind: ChangeRetyped, Old: "integer", New: "integer | string", Breaking: true}},
+		},
+		{
+			name:   "tuples",
+			differ: &Differ{Tuples: true},
+			old:    []string{`{"rows":[[1,"a"],[2,"b"]]}`},
+			new:    []string{`{"rows":[[1,"a"],[2,null]]}`},
+			want:   []Change{{Path: "$.rows[][1]", Kind: ChangeNullable, Breaking: true}},
+		},
+		{
+			name:   "tuple length",
+			differ: &Differ{Tuples: true},
+			old:    []string{`{"rows":[[1,2],[3,4]]}`},
+			new:    []string{`{"rows":[[1,2,3],[4,5,6]]}`},
+			want:   []Change{{Path: "$.rows[]", Kind: ChangeRetyped, Old: "tuple of 2", New: "tuple of 3", Breaking: true}},
+		},
+		{
+			name:   "unions",
+			differ: &Differ{Unions: true},
+			old:    []string{`{"type":"click","x":1}`, `{"type":"view","page":"/"}`},
+			new:    []string{`{"type":"click","x":1}`, `{"type":"view","page":"/"}`, `{"type":"scroll","dy":2}`},
+			want:   []Change{{Path: `$[?(@.type=="scroll")]`, Kind: ChangeAdded, New: "object"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report := test.differ.Diff(observeAll(t, test.old...), observeAll(t, test.new...))
+			if !reflect.DeepEqual(report.Changes, test.want) {
+				t.Errorf("expected %+v, got %+v", test.want, report.Changes)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package oojson
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"golang.org/x/exp/maps"
+)
+
+// A keyKind is the kind of the keys of an object.
+type keyKind int
+
+// Key kinds.
+const (
+	keysStatic keyKind = iota // keys are property names, the object is a struct
+	keysString                // keys are dynamic strings, the object is a map
+	keysInt                   // keys are dynamic integers, the object is a map
+)
+
+// dynamicValueThreshold is the similarity threshold that the values of an
+// object with dynamic keys must meet.
+const dynamicValueThreshold = 0.5
+
+var (
+	intKeyRegexp      = regexp.MustCompile(`^-?\d+$`)
+	prefixedKeyRegexp = regexp.MustCompile(`^([A-Za-z]+[_-]?)\d+$`)
+)
+
+// WithDynamicKeys sets whether objects whose keys look like data rather than
+// property names become maps. Keys look like data if they are all integers,
+// UUIDs, dates or prefixed IDs such as u_123, or if there are at least minKeys
+// of them. In either case all values must have compatible types.
+func WithDynamicKeys(minKeys int) Option {
+	return func(target optionTarget) error {
+		if minKeys < 2 {
+			return fmt.Errorf("oojson: dynamic keys need a minimum of at least 2 keys, got %d", minKeys)
+		}
+		target.common().minDynamicKeys = minKeys
+		return nil
+	}
+}
+
+// keyKind returns the kind of the keys of the object v.
+func (c *commonOption) keyKind(v *Value) keyKind {
+	if c.minDynamicKeys == 0 || len(v.ObjectProperties) < 2 {
+		return keysStatic
+	}
+	keys := maps.Keys(v.ObjectProperties)
+	sort.Strings(keys)
+	// Each value is compared with the merge of all values, which the type of
+	// the map values is generated from, so that maps with thousands of keys
+	// take linear time and the result does not depend on the order of keys.
+	all := v.AllObjectProperties
+	if all == nil {
+		all = v.ObjectProperties[keys[0]]
+	}
+	shapes := &Shapes{threshold: dynamicValueThreshold}
+	for _, key := range keys {
+		if !shapes.compatible(all, v.ObjectProperties[key]) {
+			return keysStatic
+		}
+	}
+
+	switch {
+	case allKeys(keys, intKeyRegexp.MatchString):
+		return keysInt
+	case allKeys(keys, uuidRegexp.MatchString), allKeys(keys, isDateKey), allKeys(keys, isPrefixedKey(keys[0])):
+		return keysString
+	case len(keys) >= c.minDynamicKeys:
+		return keysString
+	default:
+		return keysStatic
+	}
+}
+
+// intKeys returns a Value with the range of the integer keys of the object
+// v, so that their type is chosen like that of integers.
+func intKeys(v *Value) *Value {
+	ints := &Value{Ints: len(v.ObjectProperties)}
+	first := true
+	for key := range v.ObjectProperties {
+		i, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			if _, err := strconv.ParseUint(key, 10, 64); err == nil {
+				ints.Uint64s++
+			} else {
+				ints.BigInts++
+			}
+			continue
+		}
+		if i < 0 {
+			ints.Negatives++
+		}
+		if first || float64(i) < ints.MinNumber {
+			ints.MinNumber = float64(i)
+		}
+		if first || float64(i) > ints.MaxNumber {
+			ints.MaxNumber = float64(i)
+		}
+		first = false
+	}
+	return ints
+}
+
+// allKeys returns true if match returns true for all keys.
+func allKeys(keys []string, match func(string) bool) bool {
+	for _, key := range keys {
+		if !match(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// isDateKey returns true if key is a date or a timestamp.
+func isDateKey(key string) bool {
+	for _, layout := range []string{time.DateOnly, time.RFC3339Nano} {
+		if _, err := time.Parse(layout, key); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isPrefixedKey returns a function that returns true for IDs with the same
+// prefix as key, e.g. u_123 and u_456.
+func isPrefixedKey(key string) func(string) bool {
+	m := prefixedKeyRegexp.FindStringSubmatch(key)
+	return func(other string) bool {
+		n := prefixedKeyRegexp.FindStringSubmatch(other)
+		return m != nil && n != nil && m[1] == n[1]
+	}
+}
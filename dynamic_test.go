@@ -0,0 +1,37 @@
+package oojson
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestKeyKindIsDeterministic(t *testing.T) {
+	v := observeJSON(t, `{"byid":{"1":{"a":1,"b":1},"2":{"a":1,"b":1,"c":1},"3":{"a":"x","b":1}}}`)
+	options := DefaultGoOption(WithDynamicKeys(10))
+	// Only the last value has a string a, whichever key comes first.
+	for i := 0; i < 50; i++ {
+		if kind := options.keyKind(v.ObjectProperties["byid"]); kind != keysStatic {
+			t.Fatalf("run %d: expected static keys, got %v", i, kind)
+		}
+	}
+}
+
+func TestKeyKindLargeMap(t *testing.T) {
+	var entries []string
+	for i := 0; i < 5000; i++ {
+		entries = append(entries, fmt.Sprintf(`"u_%d":{"name":"n%d","tags":[{"id":%d}]}`, i, i, i))
+	}
+	v := observeJSON(t, `{"users":{`+strings.Join(entries, ",")+`}}`)
+	options := DefaultGoOption(WithDynamicKeys(10))
+	if kind := options.keyKind(v.ObjectProperties["users"]); kind != keysString {
+		t.Errorf("expected string keys, got %v", kind)
+	}
+}
+
+func TestIntKeyType(t *testing.T) {
+	src := generateGoFile(t, `{"byid":{"1":{"a":1},"200":{"a":2}}}`, WithDynamicKeys(10), WithAutoIntType(true))
+	if !strings.Contains(src, "map[int16]") {
+		t.Errorf("expected an int16 key, got\n%s", src)
+	}
+}
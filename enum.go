@@ -0,0 +1,80 @@
+package oojson
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/maps"
+)
+
+// An enum is the closed set of string or integer values observed for a
+// Value.
+type enum struct {
+	strings []string
+	ints    []int64
+}
+
+// WithEnums sets whether properties with at most maxValues distinct string or
+// integer values become enums. Each value must have been observed twice on
+// average, so that a single sample does not produce enums.
+func WithEnums(maxValues int) Option {
+	return func(target optionTarget) error {
+		if maxValues < 1 {
+			return fmt.Errorf("oojson: enums need at least one value, got %d", maxValues)
+		}
+		target.common().maxEnumValues = maxValues
+		return nil
+	}
+}
+
+// enum returns the enum of v, or nil if enums are disabled or v does not
+// have a small closed set of values.
+func (c *commonOption) enum(v *Value) *enum {
+	if c.maxEnumValues == 0 || v.TooManyValues {
+		return nil
+	}
+	switch {
+	case v.Strings > 0 && v.Strings+v.Nulls == v.Observations && v.Times < v.Strings:
+		if !isEnumCardinality(len(v.StringValues), v.Strings, c.maxEnumValues) {
+			return nil
+		}
+		values := maps.Keys(v.StringValues)
+		sort.Strings(values)
+		return &enum{strings: values}
+	case v.Ints > 0 && v.Ints+v.Nulls == v.Observations:
+		// Integers beyond int64 are not tracked in IntValues, so the enum
+		// would lack them.
+		if v.Uint64s+v.BigInts > 0 || !isEnumCardinality(len(v.IntValues), v.Ints, c.maxEnumValues) {
+			return nil
+		}
+		values := maps.Keys(v.IntValues)
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+		return &enum{ints: values}
+	default:
+		return nil
+	}
+}
+
+// oneOf returns the parameter of a validator oneof rule for e, and false if
+// some value cannot be expressed in one.
+func (e *enum) oneOf() (string, bool) {
+	var values []string
+	for _, value := range e.strings {
+		if value == "" || strings.ContainsAny(value, " ,|'\"`") {
+			return "", false
+		}
+		values = append(values, value)
+	}
+	for _, value := range e.ints {
+		values = append(values, strconv.FormatInt(value, 10))
+	}
+	return strings.Join(values, " "), true
+}
+
+// isEnumCardinality returns true if distinct values observed count times in
+// total are few enough to form an enum.
+func isEnumCardinality(distinct, count, maxValues int) bool {
+	return distinct > 0 && distinct <= maxValues && count >= 2*distinct
+}
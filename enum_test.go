@@ -0,0 +1,20 @@
+package oojson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnumExcludesUint64s(t *testing.T) {
+	src := generateGoFile(t, `{"items":[{"n":1},{"n":1},{"n":18446744073709551615},{"n":18446744073709551615}]}`, WithEnums(8))
+	if strings.Contains(src, "Valid()") || !strings.Contains(src, "N uint64") {
+		t.Errorf("expected a uint64 field and no enum, got\n%s", src)
+	}
+}
+
+func TestEnumIntType(t *testing.T) {
+	src := generateGoFile(t, `{"items":[{"n":1},{"n":1},{"n":300},{"n":300}]}`, WithEnums(8), WithAutoIntType(true))
+	if !strings.Contains(src, "type ItemN int16") {
+		t.Errorf("expected an int16 enum, got\n%s", src)
+	}
+}
@@ -0,0 +1,118 @@
+package oojson
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// ObserveFiles observes the files at paths with the default settings.
+func ObserveFiles(ctx context.Context, paths []string, workers int) (*Value, error) {
+	return NewObserver().ObserveFiles(ctx, paths, workers)
+}
+
+// ObserveFiles observes the JSON documents, NDJSON or concatenated JSON values
+// in the files at paths with workers goroutines, or GOMAXPROCS if workers is
+// less than one, and merges them in the order of paths. It stops at the first
+// error or when ctx is done. Progress is not reported. The Value is nil if
+// there are no paths.
+func (o *Observer) ObserveFiles(ctx context.Context, paths []string, workers int) (*Value, error) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	fileObserver := *o
+	fileObserver.Progress = nil
+
+	type fileResult struct {
+		index int
+		value *Value
+		err   error
+	}
+	jobs := make(chan int)
+	results := make(chan fileResult)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				value, err := fileObserver.observeFile(ctx, paths[index])
+				select {
+				case results <- fileResult{index: index, value: value, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for index := range paths {
+			select {
+			case jobs <- index:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Merge the files in order so that the result does not depend on which
+	// worker finishes first.
+	var v *Value
+	pending := make(map[int]*Value)
+	next := 0
+	for result := range results {
+		if result.err != nil {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("oojson: %s: %w", paths[result.index], result.err)
+		}
+		pending[result.index] = result.value
+		for value, ok := pending[next]; ok; value, ok = pending[next] {
+			delete(pending, next)
+			if v == nil {
+				v = value
+			} else {
+				o.Merge(v, value)
+			}
+			next++
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// observeFile observes the values in the file at path until ctx is done.
+func (o *Observer) observeFile(ctx context.Context, path string) (*Value, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return o.ObserveReader(nil, contextReader{ctx: ctx, r: f}, StreamValues)
+}
+
+// A contextReader is a reader that fails once its context is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,67 @@
+package oojson
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles writes each of contents to a file in a temporary directory and
+// returns their paths.
+func writeFiles(t *testing.T, contents ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	paths := make([]string, len(contents))
+	for i, content := range contents {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("%d.json", i))
+		if err := os.WriteFile(paths[i], []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return paths
+}
+
+func TestObserveFilesMergeOrder(t *testing.T) {
+	var contents []string
+	var sequential *Value
+	for i := 0; i < 32; i++ {
+		content := fmt.Sprintf(`{"k%d":%d,"shared":"%d"}`, i, i, i)
+		contents = append(contents, content)
+		var err error
+		if sequential, err = NewObserver().ObserveJSON(sequential, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	paths := writeFiles(t, contents...)
+	want := &bytes.Buffer{}
+	if err := sequential.Save(want); err != nil {
+		t.Fatal(err)
+	}
+	for run := 0; run < 10; run++ {
+		v, err := ObserveFiles(context.Background(), paths, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := &bytes.Buffer{}
+		if err := v.Save(got); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got.Bytes(), want.Bytes()) {
+			t.Fatalf("expected the result of observing the files in order, got\n%s", got)
+		}
+	}
+}
+
+func TestObserveFilesCanceled(t *testing.T) {
+	paths := writeFiles(t, `{"a":1}`, `{"a":2}`, `{"a":3}`)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	v, err := ObserveFiles(ctx, paths, 2)
+	if !errors.Is(err, context.Canceled) || v != nil {
+		t.Errorf("expected context.Canceled and no Value, got %v and %+v", err, v)
+	}
+}
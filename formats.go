@@ -0,0 +1,123 @@
+package oojson
+
+import (
+	"encoding/base64"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Semantic string formats recognised by the default FormatRegistry.
+const (
+	FormatUUID     = "uuid"
+	FormatEmail    = "email"
+	FormatURI      = "uri"
+	FormatIPv4     = "ipv4"
+	FormatIPv6     = "ipv6"
+	FormatSemver   = "semver"
+	FormatHostname = "hostname"
+	FormatHex      = "hex"
+	FormatBase64   = "base64"
+)
+
+// A FormatDetector recognises strings of a semantic format.
+type FormatDetector struct {
+	Name   string
+	Detect func(string) bool
+}
+
+// A FormatRegistry is an ordered set of FormatDetectors. When all strings of
+// a Value match several formats, generators use the first one.
+type FormatRegistry struct {
+	detectors []FormatDetector
+}
+
+var (
+	uuidRegexp     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	semverRegexp   = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+	hostnameRegexp = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)+[a-zA-Z]{2,63}$`)
+	hexRegexp      = regexp.MustCompile(`^(?:[0-9a-f]{2}){4,}$|^(?:[0-9A-F]{2}){4,}$`)
+	base64Regexp   = regexp.MustCompile(`^(?:[A-Za-z0-9+/]{4}){3,}(?:[A-Za-z0-9+/]{2}==|[A-Za-z0-9+/]{3}=)?$`)
+)
+
+// NewFormatRegistry returns a FormatRegistry with detectors for UUIDs, email
+// addresses, URIs, IPv4 and IPv6 addresses, semantic versions, hostnames, hex
+// and base64.
+func NewFormatRegistry() *FormatRegistry {
+	return &FormatRegistry{
+		detectors: []FormatDetector{
+			{Name: FormatUUID, Detect: uuidRegexp.MatchString},
+			{Name: FormatEmail, Detect: isEmail},
+			{Name: FormatURI, Detect: isURI},
+			{Name: FormatIPv4, Detect: isIPv4},
+			{Name: FormatIPv6, Detect: isIPv6},
+			{Name: FormatSemver, Detect: semverRegexp.MatchString},
+			{Name: FormatHostname, Detect: hostnameRegexp.MatchString},
+			{Name: FormatHex, Detect: isHex},
+			{Name: FormatBase64, Detect: isBase64},
+		},
+	}
+}
+
+// Register adds a detector that takes precedence over the detectors already
+// in r.
+func (r *FormatRegistry) Register(name string, detect func(string) bool) {
+	r.detectors = append([]FormatDetector{{Name: name, Detect: detect}}, r.detectors...)
+}
+
+// Detectors returns the detectors in r in order of precedence.
+func (r *FormatRegistry) Detectors() []FormatDetector {
+	return append([]FormatDetector(nil), r.detectors...)
+}
+
+// format returns the first format in r that all strings of v match, or the
+// empty string if there is none.
+func (r *FormatRegistry) format(v *Value) string {
+	if v.Strings == 0 {
+		return ""
+	}
+	for _, detector := range r.detectors {
+		if v.Formats[detector.Name] == v.Strings {
+			return detector.Name
+		}
+	}
+	return ""
+}
+
+func isEmail(s string) bool {
+	address, err := mail.ParseAddress(s)
+	return err == nil && address.Address == s
+}
+
+func isURI(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+func isIPv4(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	return err == nil && addr.Is4()
+}
+
+func isIPv6(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	return err == nil && addr.Is6()
+}
+
+// isHex returns true if s is at least four bytes of hex with at least one
+// letter, so that plain numbers are not hex.
+func isHex(s string) bool {
+	return hexRegexp.MatchString(s) && strings.ContainsAny(s, "abcdefABCDEF")
+}
+
+// isBase64 returns true if s is padded standard base64 of at least nine bytes
+// that is not a plain word.
+func isBase64(s string) bool {
+	if !base64Regexp.MatchString(s) || !strings.ContainsAny(s, "0123456789+/=") {
+		return false
+	}
+	_, err := base64.StdEncoding.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,53 @@
+package oojson
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestStringFormats(t *testing.T) {
+	observer := NewObserver()
+	observer.Formats.Register("sku", regexp.MustCompile(`^SKU-\d+$`).MatchString)
+	v, err := observer.ObserveJSON(nil, []byte(`{
+		"id":"123e4567-e89b-12d3-a456-426614174000","email":"a@b.co","site":"https://x.org/a",
+		"ip":"10.0.0.1","ver":"1.2.3","sku":"SKU-42","name":"plain text"
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := []Option{WithStringFormats(observer.Formats)}
+
+	goType := strings.Join(strings.Fields(GetGoType(v, DefaultGoOption(options...))), " ")
+	for _, want := range []string{"ID uuid.UUID", "Ip netip.Addr", "Name string", "Sku string"} {
+		if !strings.Contains(goType, want) {
+			t.Errorf("expected %s in Go, got %s", want, goType)
+		}
+	}
+	validatorType, _ := GetGoValidator(v, 0, DefaultGoOption(options...))
+	for _, want := range []string{`validate:"email"`, `validate:"url"`, `validate:"semver"`, `validate:"ipv4"`, "Name string `json:\"name\"`"} {
+		if !strings.Contains(validatorType, want) {
+			t.Errorf("expected %s in the validator, got %s", want, validatorType)
+		}
+	}
+	_, tsCode := GetTsType(v, "Root", "  ", DefaultTsOption(options...))
+	for _, want := range []string{"id: UUID;", "sku: Sku;", "name: string;", `type Sku = string & { readonly __brand: "sku" };`} {
+		if !strings.Contains(tsCode, want) {
+			t.Errorf("expected %s in TypeScript, got\n%s", want, tsCode)
+		}
+	}
+	_, javaCode := GetJavaType(v, "Root", "  ", DefaultJavaOption(options...))
+	for _, want := range []string{"private UUID id;", "private URI site;", "private String sku;"} {
+		if !strings.Contains(javaCode, want) {
+			t.Errorf("expected %s in Java, got\n%s", want, javaCode)
+		}
+	}
+
+	// Without WithStringFormats the formats are only counted.
+	if goType := GetGoType(v, DefaultGoOption()); strings.Contains(goType, "uuid") {
+		t.Errorf("expected no formats without WithStringFormats, got %s", goType)
+	}
+	if v.ObjectProperties["sku"].Formats["sku"] != 1 {
+		t.Errorf("expected the registered format to be counted, got %v", v.ObjectProperties["sku"].Formats)
+	}
+}
@@ -5,12 +5,12 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"strconv"
 	"strings"
 
 	"go/printer"
 
 	"github.com/fatih/structtag"
-	"golang.org/x/exp/maps"
 )
 
 var stringIdent = ast.NewIdent("string")
@@ -24,9 +24,31 @@ var stringPointerIdent = &ast.StarExpr{X: stringIdent}
 var boolPointerIdent = &ast.StarExpr{X: boolIdent}
 var float64PointerIdent = &ast.StarExpr{X: float64Ident}
 var jsonNumberPointerIdent = &ast.StarExpr{X: jsonNumberIdent}
-var timePointerIdent = &ast.StarExpr{X: timeIdent}
 var emptyStructPointerIdent = &ast.StarExpr{X: emptyStructIdent}
 
+// A goFormatType is the Go type of strings of a semantic format.
+type goFormatType struct {
+	goType     string
+	importPath string
+}
+
+// goFormatTypes are the Go types of the semantic formats that have one.
+// Strings of other formats stay strings.
+var goFormatTypes = map[string]goFormatType{
+	FormatUUID:   {goType: "uuid.UUID", importPath: "github.com/google/uuid"},
+	FormatIPv4:   {goType: "netip.Addr", importPath: "net/netip"},
+	FormatIPv6:   {goType: "netip.Addr", importPath: "net/netip"},
+	FormatBase64: {goType: "[]byte"},
+}
+
+// goFormatType returns the Go type of formatType and adds its import.
+func (o *GoOption) goFormatType(formatType goFormatType) ast.Expr {
+	if formatType.importPath != "" {
+		o.Imports[formatType.importPath] = struct{}{}
+	}
+	return ast.NewIdent(formatType.goType)
+}
+
 // goType returns the Go type of v.
 func GetGoType(v *Value, options *GoOption) string {
 	goType, _ := GetGoAst(v, 0, options)
@@ -35,7 +57,19 @@ func GetGoType(v *Value, options *GoOption) string {
 	return buf.String()
 }
 
+// GetGoAst returns the Go type of v and whether it should be omitted when
+// empty. observations is the number of times v's parent was observed.
 func GetGoAst(v *Value, observations int, options *GoOption) (ast.Expr, bool) {
+	options.unifyShapes(v)
+	options.resolveOverrides(v)
+	options.nullableTypeName = ""
+	options.helperTypeNames = make(map[string]string)
+	return getGoAst(v, observations, "", options)
+}
+
+// getGoAst is GetGoAst for a value whose type is named name if named types
+// are enabled. The root value has an empty name and is never named.
+func getGoAst(v *Value, observations int, name string, options *GoOption) (ast.Expr, bool) {
 	// Determine the number of distinct types observed.
 	distinctTypes := 0
 	if v.Arrays > 0 {
@@ -60,27 +94,48 @@ func GetGoAst(v *Value, observations int, options *GoOption) (ast.Expr, bool) {
 		distinctTypes++
 	}
 
+	e := options.enum(v)
+	formatType, hasFormatType := goFormatTypes[options.stringFormat(v)]
+	ts := options.timestamp(v)
+	sum := options.sumType(v)
+
 	// Based on the observed distinct types, find the most specific Go type.
 	switch {
+	case ts != nil && distinctTypes == 1:
+		return options.goTimeType(ts), v.Observations < observations
+	case ts != nil && distinctTypes == 2:
+		return &ast.StarExpr{X: options.goTimeType(ts)}, false
+	case e != nil && distinctTypes == 1:
+		enumType := ast.NewIdent(declareGoEnum(v, e, name, options))
+		return enumType, v.Strings+v.Ints < observations && v.Zeros == 0
+	case e != nil && distinctTypes == 2:
+		return &ast.StarExpr{X: ast.NewIdent(declareGoEnum(v, e, name, options))}, false
 	case distinctTypes == 1 && v.Arrays > 0:
 		fallthrough
 	case distinctTypes == 2 && v.Arrays > 0 && v.Nulls > 0:
-		elementGoType, _ := GetGoAst(v.ArrayElements, 0, options)
+		if indexes, heterogeneous := options.tuple(v); indexes != nil {
+			tupleGoType := getGoTupleAst(v, indexes, heterogeneous, name, options)
+			if v.Nulls > 0 {
+				return &ast.StarExpr{X: tupleGoType}, false
+			}
+			return tupleGoType, false
+		}
+		elementGoType, _ := getGoAst(v.ArrayElements, 0, singularize(name), options)
 		return &ast.ArrayType{Lbrack: token.NoPos, Elt: elementGoType}, v.Arrays+v.Nulls < observations && v.Emptys == 0
 	case distinctTypes == 1 && v.Bools > 0:
-		return boolIdent, v.Bools < observations && v.Emptys == 0
+		return boolIdent, v.Bools < observations && v.Zeros == 0
 	case distinctTypes == 2 && v.Bools > 0 && v.Nulls > 0:
 		return boolPointerIdent, false
 	case distinctTypes == 1 && v.Float64s > 0:
-		return float64Ident, v.Float64s < observations && v.Emptys == 0
+		return float64Ident, v.Float64s < observations && v.Zeros == 0
 	case distinctTypes == 2 && v.Float64s > 0 && v.Nulls > 0:
 		return float64PointerIdent, false
 	case distinctTypes == 1 && v.Ints > 0:
-		return ast.NewIdent(options.intType), v.Ints < observations && v.Emptys == 0
+		return ast.NewIdent(options.goIntType(v)), v.Ints < observations && v.Zeros == 0
 	case distinctTypes == 2 && v.Ints > 0 && v.Nulls > 0:
-		return &ast.StarExpr{X: ast.NewIdent(options.intType)}, false
+		return &ast.StarExpr{X: ast.NewIdent(options.goIntType(v))}, false
 	case distinctTypes == 2 && v.Float64s > 0 && v.Ints > 0:
-		omitEmpty := v.Float64s+v.Ints < observations && v.Emptys == 0
+		omitEmpty := v.Float64s+v.Ints < observations && v.Zeros == 0
 		if options.useJSONNumber {
 			options.Imports["encoding/json"] = struct{}{}
 			return jsonNumberIdent, omitEmpty
@@ -115,100 +170,243 @@ func GetGoAst(v *Value, observations int, options *GoOption) (ast.Expr, bool) {
 			}
 		}
 		if hasUnparsableProperties && !options.skipUnparseableProperties {
-			valueGoType, _ := GetGoAst(v.AllObjectProperties, 0, options)
+			valueGoType, _ := getGoAst(v.AllObjectProperties, 0, name+"Value", options)
 			return &ast.MapType{Map: token.NoPos, Key: stringIdent, Value: valueGoType}, v.Objects+v.Nulls < observations
 		}
-
-		structType := &ast.StructType{
-			Struct: token.NoPos,
-			Fields: &ast.FieldList{
-				Opening: token.NoPos,
-				Closing: token.NoPos,
-				List:    []*ast.Field{},
-			},
-		}
-
-		properties := maps.Keys(v.ObjectProperties)
-		var unparsableProperties []string
-		for _, property := range properties {
-			if isUnparsableProperty(property) {
-				unparsableProperties = append(unparsableProperties, property)
-				continue
-			}
-
-			goType, observedEmpty := GetGoAst(v.ObjectProperties[property], v.Objects, options)
-			var omitEmpty bool
-			switch {
-			case options.omitEmptyOption == OmitEmptyNever:
-				omitEmpty = false
-			case options.omitEmptyOption == OmitEmptyAlways:
-				omitEmpty = true
-			case options.omitEmptyOption == OmitEmptyAuto:
-				omitEmpty = observedEmpty
+		if keyKind := options.keyKind(v); keyKind != keysStatic {
+			keyGoType := stringIdent
+			if keyKind == keysInt {
+				keyGoType = ast.NewIdent(options.goIntType(intKeys(v)))
 			}
-
-			tags, _ := structtag.Parse("")
-			var structTagOptions []string
-			if omitEmpty {
-				structTagOptions = append(structTagOptions, "omitempty")
-			}
-			for _, structTagName := range options.structTagNames {
-				tag := &structtag.Tag{
-					Key:     structTagName,
-					Name:    property,
-					Options: structTagOptions,
-				}
-				_ = tags.Set(tag)
-			}
-
-			f := &ast.Field{
-				Names: []*ast.Ident{ast.NewIdent(property)},
-				Type:  goType,
-				Tag:   &ast.BasicLit{Kind: token.STRING, Value: fmt.Sprintf("`%v`", tags.String())},
-			}
-			structType.Fields.List = append(structType.Fields.List, f)
+			valueGoType, _ := getGoAst(v.AllObjectProperties, 0, singularize(name), options)
+			return &ast.MapType{Map: token.NoPos, Key: keyGoType, Value: valueGoType}, v.Objects+v.Nulls < observations
 		}
 
-		unparsableComments := &ast.CommentGroup{}
-		for _, property := range unparsableProperties {
-			unparsableComments.List = append(unparsableComments.List, &ast.Comment{
-				Slash: token.NoPos,
-				Text:  fmt.Sprintf("// %q cannot be unmarshalled into a struct field by encoding/json.", property),
-			})
-		}
-		if len(unparsableProperties) > 0 {
-			structType.Fields.List = append(structType.Fields.List, &ast.Field{
-				Type:    ast.NewIdent(""),
-				Comment: unparsableComments,
-			})
+		recursive := options.isRecursive(v)
+		var objectType ast.Expr
+		if u := options.union(v); u != nil {
+			objectType = ast.NewIdent(declareGoUnion(u, name, options))
+		} else {
+			objectType = getGoObjectAst(v, name, options)
 		}
 
+		// Objects are pointers if they were null or absent, as encoding/json
+		// never omits a struct value, or if they refer to an enclosing type.
 		switch {
 		case observations == 0:
-			return structType, false
-		case v.Objects == observations:
-			return structType, false
-		case v.Objects < observations && v.Nulls == 0:
-			return &ast.StarExpr{X: structType}, true
+			return objectType, false
+		case v.Objects == observations && !recursive:
+			return objectType, false
 		default:
-			return &ast.StarExpr{X: structType}, v.Objects+v.Nulls < observations
+			return &ast.StarExpr{X: objectType}, v.Objects+v.Nulls < observations
 		}
-	case distinctTypes == 1 && v.Strings > 0 && v.Times == v.Strings:
-		options.Imports["time"] = struct{}{}
-		return timeIdent, v.Times < observations
+	case distinctTypes == 1 && v.Strings > 0 && hasFormatType:
+		return options.goFormatType(formatType), v.Strings < observations && v.Zeros == 0
 	case distinctTypes == 1 && v.Strings > 0:
-		return stringIdent, v.Strings < observations && v.Emptys == 0
-	case distinctTypes == 2 && v.Strings > 0 && v.Nulls > 0 && v.Times == v.Strings:
-		options.Imports["time"] = struct{}{}
-		return timePointerIdent, false
+		return stringIdent, v.Strings < observations && v.Zeros == 0
+	case distinctTypes == 2 && v.Strings > 0 && v.Nulls > 0 && hasFormatType:
+		if formatType.goType == "[]byte" {
+			return options.goFormatType(formatType), false
+		}
+		return &ast.StarExpr{X: options.goFormatType(formatType)}, false
 	case distinctTypes == 2 && v.Strings > 0 && v.Nulls > 0:
 		return stringPointerIdent, false
+	case sum != nil:
+		return ast.NewIdent(options.declareGoSumType(v, sum)), false
 	default:
 		return anyIdent, v.Arrays+v.Bools+v.Float64s+v.Ints+v.Nulls+v.Objects+v.Strings < observations
 	}
 }
 
+// getGoObjectAst returns the struct type of the object v, or the name of the
+// type declared for it if it is named or shares its shape with other objects.
+func getGoObjectAst(v *Value, name string, options *GoOption) ast.Expr {
+	shape, shared := options.shapes.shapeOf(v)
+	if typeName, ok := options.shapeNames[shape]; ok {
+		return ast.NewIdent(typeName)
+	}
+	var typeSpec *ast.TypeSpec
+	switch {
+	case shared && name == "" && options.rootTypeName != "":
+		// The root shares its shape with its descendants, so the root type is
+		// the shared type.
+		typeSpec = &ast.TypeSpec{Name: ast.NewIdent(options.rootTypeName)}
+		options.Decls = append(options.Decls, &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{typeSpec}})
+		options.shapeNames[shape] = typeSpec.Name.Name
+	case shared:
+		typeSpec = options.declareGoType(options.shapes.name(shape, name))
+		options.shapeNames[shape] = typeSpec.Name.Name
+	case options.namedTypes && name != "":
+		typeSpec = options.declareGoType(name)
+	}
+	if typeSpec != nil {
+		name = typeSpec.Name.Name
+	}
+
+	done := options.enclose(shape)
+	structType := getGoStructAst(shape, name, options)
+	done()
+	if typeSpec != nil {
+		typeSpec.Type = structType
+		return ast.NewIdent(typeSpec.Name.Name)
+	}
+	return structType
+}
+
+// getGoStructAst returns the struct type with a field for each property of the
+// object v. Nested types are named after name.
+func getGoStructAst(v *Value, name string, options *GoOption) *ast.StructType {
+	structType := &ast.StructType{
+		Struct: token.NoPos,
+		Fields: &ast.FieldList{
+			Opening: token.NoPos,
+			Closing: token.NoPos,
+			List:    []*ast.Field{},
+		},
+	}
+
+	properties := options.properties(v)
+	fieldNames := options.goFieldNames(v, properties)
+	var unparsableProperties []string
+	for _, property := range properties {
+		if isUnparsableProperty(property) {
+			unparsableProperties = append(unparsableProperties, property)
+			continue
+		}
+
+		override := options.override(v, property)
+		if override.Skip {
+			continue
+		}
+
+		prop := v.ObjectProperties[property]
+		var goType ast.Expr
+		var observedEmpty, nullable bool
+		stringified := ""
+		if override.GoType == "" {
+			stringified = options.goStringifiedType(prop)
+		}
+		switch {
+		case override.GoType != "":
+			goType = ast.NewIdent(override.goTypeName(options))
+		case stringified != "":
+			goType, observedEmpty = ast.NewIdent(strings.TrimPrefix(stringified, "*")), stringifiedOmitEmpty(prop, v.Objects)
+			if prop.Nulls > 0 {
+				goType = &ast.StarExpr{X: goType}
+			}
+		case options.isNullable(prop) && !override.Required:
+			goType, _ = getGoAst(prop, v.Objects, name+fieldNames[property], options)
+			goType = getGoNullableAst(goType, options)
+			nullable = true
+		default:
+			goType, observedEmpty = getGoAst(prop, v.Objects, name+fieldNames[property], options)
+		}
+		if star, ok := goType.(*ast.StarExpr); ok && override.Required && override.GoType == "" {
+			goType = star.X
+		}
+		var omitEmpty bool
+		switch {
+		case override.Optional:
+			omitEmpty = true
+		case override.Required:
+			omitEmpty = false
+		case options.omitEmptyOption == OmitEmptyNever:
+			omitEmpty = false
+		case options.omitEmptyOption == OmitEmptyAlways:
+			omitEmpty = true
+		case options.omitEmptyOption == OmitEmptyAuto:
+			omitEmpty = observedEmpty
+		}
+
+		tags, _ := structtag.Parse("")
+		var structTagOptions []string
+		if omitEmpty {
+			structTagOptions = append(structTagOptions, "omitempty")
+		}
+		if stringified != "" {
+			structTagOptions = append(structTagOptions, "string")
+		}
+		if nullable {
+			structTagOptions = append(structTagOptions, JSON_OMITZERO)
+		}
+		for _, structTagName := range options.structTagNames {
+			tag := &structtag.Tag{
+				Key:     structTagName,
+				Name:    property,
+				Options: structTagOptions,
+			}
+			_ = tags.Set(tag)
+		}
+		for _, tag := range override.goTags() {
+			_ = tags.Set(tag)
+		}
+
+		f := &ast.Field{
+			Names: []*ast.Ident{ast.NewIdent(fieldNames[property])},
+			Type:  goType,
+			Tag:   &ast.BasicLit{Kind: token.STRING, Value: fmt.Sprintf("`%v`", tags.String())},
+		}
+		structType.Fields.List = append(structType.Fields.List, f)
+	}
+
+	unparsableComments := &ast.CommentGroup{}
+	for _, property := range unparsableProperties {
+		unparsableComments.List = append(unparsableComments.List, &ast.Comment{
+			Slash: token.NoPos,
+			Text:  fmt.Sprintf("// %q cannot be unmarshalled into a struct field by encoding/json.", property),
+		})
+	}
+	if len(unparsableProperties) > 0 {
+		structType.Fields.List = append(structType.Fields.List, &ast.Field{
+			Type:    ast.NewIdent(""),
+			Comment: unparsableComments,
+		})
+	}
+	return structType
+}
+
+// declareGoEnum declares a type named after name with a constant for each
+// value of e, the enum of v, and a Valid method, and returns the name of the
+// type. Integer enums have the type that v would have without enums.
+func declareGoEnum(v *Value, e *enum, name string, options *GoOption) string {
+	if name == "" {
+		name = "Enum"
+	}
+	typeName := options.reserveGoTypeName(name)
+	underlyingType := "string"
+	if e.strings == nil {
+		underlyingType = options.goIntType(v)
+	}
+
+	b := &bytes.Buffer{}
+	var constNames []string
+	fmt.Fprintf(b, "type %s %s\n\nconst (\n", typeName, underlyingType)
+	for _, value := range e.strings {
+		valueName := "Empty"
+		if value != "" {
+			valueName = options.exportNameFunc(value)
+		}
+		constName := options.reserveGoTypeName(typeName + valueName)
+		constNames = append(constNames, constName)
+		fmt.Fprintf(b, "%s %s = %q\n", constName, typeName, value)
+	}
+	for _, value := range e.ints {
+		valueName := strconv.FormatInt(value, 10)
+		if value < 0 {
+			valueName = "Minus" + valueName[1:]
+		}
+		constName := options.reserveGoTypeName(typeName + valueName)
+		constNames = append(constNames, constName)
+		fmt.Fprintf(b, "%s %s = %d\n", constName, typeName, value)
+	}
+	fmt.Fprintf(b, ")\n\n")
+	fmt.Fprintf(b, "// Valid returns true if v is one of the observed values of %s.\n", typeName)
+	fmt.Fprintf(b, "func (v %s) Valid() bool {\nswitch v {\ncase %s:\nreturn true\n}\nreturn false\n}\n", typeName, strings.Join(constNames, ", "))
+	options.declareGoSource(b.String())
+	return typeName
+}
+
 // isUnparsableProperty returns true if key cannot be parsed by encoding/json.
 func isUnparsableProperty(key string) bool {
-	return strings.ContainsAny(key, ` ",`)
+	return key == "" || strings.ContainsAny(key, ` ",`)
 }
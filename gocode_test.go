@@ -0,0 +1,36 @@
+package oojson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAbsentObjectsArePointers(t *testing.T) {
+	v := observeJSON(t, `[{"meta":{"id":1}},{}]`)
+	goType := strings.Join(strings.Fields(GetGoType(v, DefaultGoOption())), " ")
+	if !strings.Contains(goType, "Meta *struct") || !strings.Contains(goType, `json:"meta,omitempty"`) {
+		t.Errorf("expected an omitempty pointer to an absent object, got %s", goType)
+	}
+	validatorType, _ := GetGoValidator(v, 0, DefaultGoOption())
+	if !strings.Contains(validatorType, "Meta *struct") {
+		t.Errorf("expected GetGoValidator to agree with GetGoAst, got %s", validatorType)
+	}
+}
+
+func TestNamedTypes(t *testing.T) {
+	src := generateGoFile(t, `{"name":"x","properties":[{"type":"pet","owner":{"id":1}}],"meta":{"a":1}}`, WithNamedTypes(true))
+	for _, want := range []string{
+		"\tMeta       Meta       `json:\"meta\"`\n",
+		"\tProperties []Property `json:\"properties\"`\n",
+		"type Meta struct {\n",
+		"type Property struct {\n\tOwner PropertyOwner `json:\"owner\"`\n",
+		"type PropertyOwner struct {\n\tID int `json:\"id\"`\n}\n",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("expected %q, got\n%s", want, src)
+		}
+	}
+	if strings.Contains(src, "struct {\n\t\t") {
+		t.Errorf("expected no anonymous nested structs, got\n%s", src)
+	}
+}
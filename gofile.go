@@ -0,0 +1,140 @@
+package oojson
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/maps"
+)
+
+// GoFileOptions describes a generated Go source file.
+type GoFileOptions struct {
+	Package  string    // package name, "main" if empty
+	TypeName string    // name of the root type, "Root" if empty
+	Options  *GoOption // generator options, DefaultGoOption() if nil
+}
+
+// GetGoFileAst returns a Go source file declaring the type of v.
+func GetGoFileAst(v *Value, fileOptions GoFileOptions) (*ast.File, error) {
+	packageName := fileOptions.Package
+	if packageName == "" {
+		packageName = "main"
+	}
+	if !token.IsIdentifier(packageName) {
+		return nil, fmt.Errorf("oojson: invalid package name %q", packageName)
+	}
+	typeName := fileOptions.TypeName
+	if typeName == "" {
+		typeName = "Root"
+	}
+	if !token.IsIdentifier(typeName) {
+		return nil, fmt.Errorf("oojson: invalid type name %q", typeName)
+	}
+	options := fileOptions.Options
+	if options == nil {
+		options = DefaultGoOption()
+	}
+
+	typeName = options.reserveGoTypeName(typeName)
+	declsStart := len(options.Decls)
+	options.rootTypeName = typeName
+	goType, _ := GetGoAst(v, 0, options)
+	options.rootTypeName = ""
+	var decls []ast.Decl
+	if ident, ok := goType.(*ast.Ident); !ok || ident.Name != typeName {
+		decls = append(decls, &ast.GenDecl{
+			Tok: token.TYPE,
+			Specs: []ast.Spec{
+				&ast.TypeSpec{Name: ast.NewIdent(typeName), Type: goType},
+			},
+		})
+	}
+	decls = append(decls, options.Decls[declsStart:]...)
+
+	file := &ast.File{Name: ast.NewIdent(packageName)}
+	if importDecl := getGoImportDecl(decls, options.Imports); importDecl != nil {
+		file.Decls = append(file.Decls, importDecl)
+	}
+	file.Decls = append(file.Decls, decls...)
+	return file, nil
+}
+
+// GenerateGoFile returns the formatted Go source file declaring the type of v.
+func GenerateGoFile(v *Value, fileOptions GoFileOptions) ([]byte, error) {
+	if fileOptions.Options == nil {
+		fileOptions.Options = DefaultGoOption()
+	}
+	file, err := GetGoFileAst(v, fileOptions)
+	if err != nil {
+		return nil, err
+	}
+	// Print declarations one at a time to separate them with blank lines, as
+	// the generated nodes have no positions.
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "package %s\n", file.Name.Name)
+	for _, decl := range file.Decls {
+		b.WriteString("\n")
+		if err := format.Node(b, fileOptions.Options.fset, decl); err != nil {
+			return nil, err
+		}
+		b.WriteString("\n")
+	}
+	return format.Source(b.Bytes())
+}
+
+// getGoImportDecl returns the import declaration for the imports that are
+// referenced in decls, or nil if none are.
+func getGoImportDecl(decls []ast.Decl, imports map[string]struct{}) *ast.GenDecl {
+	used := make(map[string]bool)
+	for _, decl := range decls {
+		ast.Inspect(decl, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				if x, ok := n.X.(*ast.Ident); ok {
+					used[x.Name] = true
+				}
+			case *ast.Ident:
+				// Qualified type names are built as single identifiers.
+				if i := strings.IndexByte(n.Name, '.'); i > 0 {
+					used[n.Name[:i]] = true
+				}
+			}
+			return true
+		})
+	}
+
+	paths := maps.Keys(imports)
+	sort.Strings(paths)
+	importDecl := &ast.GenDecl{Tok: token.IMPORT}
+	for _, importPath := range paths {
+		if !used[getGoPackageName(importPath)] {
+			continue
+		}
+		importDecl.Specs = append(importDecl.Specs, &ast.ImportSpec{
+			Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(importPath)},
+		})
+	}
+	if len(importDecl.Specs) == 0 {
+		return nil
+	}
+	if len(importDecl.Specs) > 1 {
+		importDecl.Lparen = 1
+	}
+	return importDecl
+}
+
+// getGoPackageName returns the conventional package name of importPath.
+func getGoPackageName(importPath string) string {
+	name := path.Base(importPath)
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(importPath))
+	}
+	return name
+}
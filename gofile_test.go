@@ -0,0 +1,114 @@
+package oojson
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// observeJSON observes the JSON document data and fails t if it is invalid.
+func observeJSON(t *testing.T, data string) *Value {
+	t.Helper()
+	v, err := ObserveJSON([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// generateGoFile returns the Go source file generated for the JSON document
+// data with opts, and fails t if it does not type-check.
+func generateGoFile(t *testing.T, data string, opts ...Option) string {
+	t.Helper()
+	v := observeJSON(t, data)
+	src, err := GenerateGoFile(v, GoFileOptions{Package: "model", Options: DefaultGoOption(opts...)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "model.go", src, 0)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, src)
+	}
+	config := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := config.Check("model", fset, []*ast.File{file}, nil); err != nil {
+		t.Fatalf("%v\n%s", err, src)
+	}
+	return string(src)
+}
+
+// runGoFile generates a Go source file for the JSON document data with opts,
+// builds it with the function main, and returns its output. main decodes into
+// the generated Root type.
+func runGoFile(t *testing.T, data string, main string, opts ...Option) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping go run in short mode")
+	}
+	goCommand, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	v := observeJSON(t, data)
+	src, err := GenerateGoFile(v, GoFileOptions{Options: DefaultGoOption(opts...)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":  "module generated\n\ngo 1.18\n",
+		"root.go": string(src),
+		"main.go": "package main\n\n" + main,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command(goCommand, "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s\n%s", err, output, src)
+	}
+	return string(output)
+}
+
+func TestGenerateGoFile(t *testing.T) {
+	v := observeJSON(t, `{"name":"Tom","born":"2000-01-02T03:04:05Z"}`)
+	options := DefaultGoOption()
+	// An import that no declaration refers to is not printed.
+	options.Imports["fmt"] = struct{}{}
+	src, err := GenerateGoFile(v, GoFileOptions{Package: "model", TypeName: "Person", Options: options})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "package model\n\nimport \"time\"\n\ntype Person struct {\n" +
+		"\tBorn time.Time `json:\"born\"`\n" +
+		"\tName string    `json:\"name\"`\n}\n"
+	if string(src) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, src)
+	}
+
+	src, err = GenerateGoFile(v, GoFileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(src), "package main\n") || !strings.Contains(string(src), "type Root struct {") {
+		t.Errorf("expected package main and type Root, got\n%s", src)
+	}
+
+	for _, fileOptions := range []GoFileOptions{{Package: "my-model"}, {TypeName: "1Person"}} {
+		if _, err := GenerateGoFile(v, fileOptions); err == nil {
+			t.Errorf("expected an error for %+v", fileOptions)
+		}
+	}
+}
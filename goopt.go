@@ -1,6 +1,12 @@
 package oojson
 
 import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 	"unicode"
@@ -10,16 +16,26 @@ import (
 )
 
 type GoOption struct {
-	exportNameFunc            ExportNameFunc
+	commonOption
 	Imports                   map[string]struct{}
+	Decls                     []ast.Decl // types and methods declared by the generators
 	RegexpValidators          map[string]string
 	intType                   string
+	intTypeSet                bool // whether WithIntType was applied
 	omitEmptyOption           OmitEmptyOption
 	skipUnparseableProperties bool
 	structTagNames            []string
 	useJSONNumber             bool
-	exportRenames             map[string]string
-	timestampFormats          []string
+	namedTypes                bool
+	autoIntType               bool
+	rangeRules                bool
+	nullableType              bool
+	nullableTypeName          string            // name of the declared Nullable type, if any
+	helperTypeNames           map[string]string // names of the declared time wrappers and sum types by key
+	rootTypeName              string            // name of the root type in generated files
+	typeNames                 map[string]bool
+	enclosingShapes           map[*Value]bool // shapes whose struct types are being generated
+	fset                      *token.FileSet  // positions of parsed declarations
 }
 
 var (
@@ -40,6 +56,24 @@ var (
 	}
 )
 
+// goPredeclaredIdentifiers are the identifiers of Go's universe scope.
+var goPredeclaredIdentifiers = map[string]bool{
+	"any": true, "bool": true, "byte": true, "comparable": true,
+	"complex64": true, "complex128": true, "error": true, "float32": true,
+	"float64": true, "int": true, "int8": true, "int16": true, "int32": true,
+	"int64": true, "rune": true, "string": true, "uint": true, "uint8": true,
+	"uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"true": true, "false": true, "iota": true, "nil": true,
+	"append": true, "cap": true, "clear": true, "close": true, "complex": true,
+	"copy": true, "delete": true, "imag": true, "len": true, "make": true,
+	"max": true, "min": true, "new": true, "panic": true, "print": true,
+	"println": true, "real": true, "recover": true,
+}
+
+// TimestampFormats are the default timestamp layouts of an Observer.
+//
+// Deprecated: Set Observer.TimestampFormats instead. NewObserver copies
+// TimestampFormats, so changes only affect Observers created afterwards.
 var TimestampFormats = []string{
 	time.RFC3339Nano,
 	time.DateTime,
@@ -52,7 +86,9 @@ var TimestampFormats = []string{
 	"2006/01/02 15:04:05",
 }
 
-func DefaultGoOption() *GoOption {
+// NewGoOption returns the default GoOption with opts applied, or an error if
+// any of opts is invalid.
+func NewGoOption(opts ...Option) (*GoOption, error) {
 	opt := &GoOption{
 		Imports: map[string]struct{}{
 			"github.com/go-playground/validator/v10": {},
@@ -63,17 +99,151 @@ func DefaultGoOption() *GoOption {
 		skipUnparseableProperties: true,
 		structTagNames:            []string{"json"},
 		useJSONNumber:             false,
+		fset:                      token.NewFileSet(),
 	}
 
 	opt.exportNameFunc = func(name string) string {
-		if rename, ok := opt.exportRenames[name]; ok {
-			return rename
-		}
 		return DefaultExportNameFunc(name, maps.Clone(defaultAbbreviations))
 	}
+	if err := applyOptions(opt, opts); err != nil {
+		return nil, err
+	}
+	return opt, nil
+}
+
+// validate returns an error if options applied to o contradict each other.
+func (o *GoOption) validate() error {
+	if o.intTypeSet && o.autoIntType {
+		return fmt.Errorf("oojson: WithIntType and WithAutoIntType(true) both set the integer type")
+	}
+	return nil
+}
+
+// DefaultGoOption is like NewGoOption but panics if any of opts is invalid.
+func DefaultGoOption(opts ...Option) *GoOption {
+	opt, err := NewGoOption(opts...)
+	if err != nil {
+		panic(err)
+	}
 	return opt
 }
 
+// goIntType returns the Go type of the integers observed for v. Integers that
+// do not fit in int64 are always generated as uint64 or json.Number.
+func (o *GoOption) goIntType(v *Value) string {
+	switch {
+	case v.BigInts > 0 || v.Uint64s > 0 && v.Negatives > 0:
+		o.Imports["encoding/json"] = struct{}{}
+		return "json.Number"
+	case v.Uint64s > 0:
+		return "uint64"
+	case !o.autoIntType:
+		return o.intType
+	case v.MinNumber >= math.MinInt8 && v.MaxNumber <= math.MaxInt8:
+		return "int8"
+	case v.MinNumber >= math.MinInt16 && v.MaxNumber <= math.MaxInt16:
+		return "int16"
+	case v.MinNumber >= math.MinInt32 && v.MaxNumber <= math.MaxInt32:
+		return "int32"
+	default:
+		return "int64"
+	}
+}
+
+// reserveGoTypeName returns a type name based on name that has not been
+// returned before and reserves it.
+func (o *GoOption) reserveGoTypeName(name string) string {
+	if o.typeNames == nil {
+		o.typeNames = make(map[string]bool)
+	}
+	base := escapeGoIdentifier(name)
+	name = base
+	for i := 2; o.typeNames[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	o.typeNames[name] = true
+	return name
+}
+
+// isRecursive returns true if the object v has the shape of a struct type
+// that is being generated, so that fields of its type must be pointers.
+func (o *GoOption) isRecursive(v *Value) bool {
+	shape, _ := o.shapes.shapeOf(v)
+	return o.enclosingShapes[shape]
+}
+
+// enclose marks shape as enclosing the fields generated until the returned
+// function is called.
+func (o *GoOption) enclose(shape *Value) func() {
+	if o.enclosingShapes == nil {
+		o.enclosingShapes = make(map[*Value]bool)
+	}
+	o.enclosingShapes[shape] = true
+	return func() {
+		delete(o.enclosingShapes, shape)
+	}
+}
+
+// declareGoType appends a declaration of a type named after name to o.Decls
+// and returns its spec. The caller sets the spec's type.
+func (o *GoOption) declareGoType(name string) *ast.TypeSpec {
+	typeSpec := &ast.TypeSpec{Name: ast.NewIdent(o.reserveGoTypeName(name))}
+	o.Decls = append(o.Decls, &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{typeSpec}})
+	return typeSpec
+}
+
+// declareGoSource parses the declarations in src and appends them to o.Decls.
+func (o *GoOption) declareGoSource(src string) {
+	file, err := parser.ParseFile(o.fset, "", "package p\n\n"+src, parser.ParseComments)
+	if err != nil {
+		panic(fmt.Sprintf("oojson: generated invalid declarations %s: %v", src, err))
+	}
+	o.Decls = append(o.Decls, file.Decls...)
+}
+
+// singularize returns the singular form of the plural English name, which is
+// used to name array elements.
+func singularize(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case name == "":
+		return "Item"
+	case strings.HasSuffix(lower, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "xes"),
+		strings.HasSuffix(lower, "ches"), strings.HasSuffix(lower, "shes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(lower, "s") && !strings.HasSuffix(lower, "ss") &&
+		!strings.HasSuffix(lower, "us") && !strings.HasSuffix(lower, "is") && len(name) > 1:
+		return name[:len(name)-1]
+	default:
+		return name + "Item"
+	}
+}
+
+// goFieldNames returns the Go field name of each of the properties of the
+// object v, which are valid identifiers disambiguated as in fieldNames.
+func (o *GoOption) goFieldNames(v *Value, properties []string) map[string]string {
+	return o.fieldNames(v, properties, func(property string) string {
+		return escapeGoIdentifier(o.exportName(property))
+	})
+}
+
+// escapeGoIdentifier returns name changed so that it is a valid Go identifier
+// that does not shadow a keyword or predeclared identifier.
+func escapeGoIdentifier(name string) string {
+	switch {
+	case name == "" || name == "_":
+		return "Field"
+	case token.IsKeyword(name) || goPredeclaredIdentifiers[name]:
+		return name + "_"
+	case !token.IsIdentifier(name):
+		return DefaultExportNameFunc(name, nil)
+	default:
+		return name
+	}
+}
+
 // DefaultExportNameFunc returns the exported name for name.
 func DefaultExportNameFunc(name string, abbreviations map[string]bool) string {
 	components := SplitComponents(name)
@@ -93,14 +263,18 @@ func DefaultExportNameFunc(name string, abbreviations map[string]bool) string {
 		}
 	}
 	runes := []rune(strings.Join(components, ""))
+	if len(runes) == 0 {
+		return "_"
+	}
 	for i, r := range runes {
 		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
 			runes[i] = '_'
 		}
 	}
 	exportName := string(runes)
-	if !unicode.IsLetter(runes[0]) && runes[0] != '_' {
-		exportName = "_" + exportName
+	if !unicode.IsUpper(runes[0]) {
+		// Names such as "2fa" or "_id" would not be exported.
+		exportName = "X" + exportName
 	}
 	return exportName
 }
@@ -0,0 +1,29 @@
+package oojson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDigitKeysAreExported(t *testing.T) {
+	src := generateGoFile(t, `{"1":1,"2fa":true}`)
+	fields := strings.Join(strings.Fields(src), " ")
+	for _, field := range []string{"X1 int", "X2Fa bool"} {
+		if !strings.Contains(fields, field) {
+			t.Errorf("expected field %s in\n%s", field, src)
+		}
+	}
+}
+
+func TestFieldNameCollisions(t *testing.T) {
+	v := observeJSON(t, `{"user_id":1,"userId":2,"UserID":3}`)
+	_, tsCode := GetTsType(v, "Root", "  ", DefaultTsOption())
+	_, javaCode := GetJavaType(v, "Root", "  ", DefaultJavaOption())
+	for _, code := range []string{tsCode, javaCode} {
+		for _, name := range []string{"userId", "userId2", "userId3"} {
+			if n := strings.Count(code, " "+name+";") + strings.Count(code, " "+name+":"); n != 1 {
+				t.Errorf("expected field %s once, got %d in\n%s", name, n, code)
+			}
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 const (
 	JSON_OMITEMPTY = "omitempty"
+	JSON_OMITZERO  = "omitzero"
 )
 
 type entry struct {
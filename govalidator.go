@@ -3,15 +3,43 @@ package oojson
 import (
 	"bytes"
 	"fmt"
+	"go/ast"
+	"go/parser"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"golang.org/x/exp/maps"
 )
 
-// goType returns the Go type of v.
+// formatValidatorTags are the validator tags of semantic string formats.
+var formatValidatorTags = map[string]string{
+	FormatUUID:     "uuid",
+	FormatEmail:    "email",
+	FormatURI:      "url",
+	FormatIPv4:     "ipv4",
+	FormatIPv6:     "ipv6",
+	FormatSemver:   "semver",
+	FormatHostname: "hostname",
+	FormatHex:      "hexadecimal",
+	FormatBase64:   "base64",
+}
+
+// GetGoValidator returns the Go type of v and the struct tags, including
+// validator tags, of a field of that type. observations is the number of times
+// v's parent was observed.
 func GetGoValidator(v *Value, observations int, options *GoOption) (string, map[string]*StructTag) {
+	options.unifyShapes(v)
+	options.resolveOverrides(v)
+	options.nullableTypeName = ""
+	options.helperTypeNames = make(map[string]string)
+	return getGoValidator(v, observations, "", options)
+}
+
+// getGoValidator is GetGoValidator for a value whose type is named name if
+// named types are enabled.
+func getGoValidator(v *Value, observations int, name string, options *GoOption) (string, map[string]*StructTag) {
 	// Determine the number of distinct types observed.
 	distinctTypes := 0
 	if v.Arrays > 0 {
@@ -42,12 +70,50 @@ func GetGoValidator(v *Value, observations int, options *GoOption) (string, map[
 	tagMap[jsonTag.Key] = jsonTag
 	tagMap[validatorTag.Key] = validatorTag
 
+	e := options.enum(v)
+	ts := options.timestamp(v)
+	sum := options.sumType(v)
+
 	// Based on the observed distinct types, find the most specific Go type.
 	switch {
+	case e != nil && distinctTypes == 1:
+		enumType := declareGoEnum(v, e, name, options)
+		if oneOf, ok := e.oneOf(); ok {
+			validatorTag.Set("oneof", oneOf)
+		}
+		if v.Strings+v.Ints < observations && v.Zeros == 0 {
+			jsonTag.Set(JSON_OMITEMPTY, "")
+		}
+		return enumType, tagMap
+	case e != nil && distinctTypes == 2:
+		enumType := declareGoEnum(v, e, name, options)
+		if oneOf, ok := e.oneOf(); ok {
+			validatorTag.Set("omitempty", "")
+			validatorTag.Set("oneof", oneOf)
+		}
+		return "*" + enumType, tagMap
 	case distinctTypes == 1 && v.Arrays > 0:
 		fallthrough
 	case distinctTypes == 2 && v.Arrays > 0 && v.Nulls > 0:
-		elementGoType, elementTagMap := GetGoValidator(v.ArrayElements, 0, options)
+		if indexes, heterogeneous := options.tuple(v); indexes != nil {
+			length := strconv.Itoa(len(indexes))
+			if heterogeneous {
+				tupleType := declareGoTuple(indexes, name, options)
+				if v.Nulls > 0 {
+					return "*" + tupleType, tagMap
+				}
+				tagMap["validate"].Set("required", "")
+				return tupleType, tagMap
+			}
+			elementGoType, elementTagMap := getGoValidator(v.ArrayElements, 0, singularize(name), options)
+			elementTagMap["validate"].Prepend("dive", "")
+			if v.Nulls > 0 {
+				elementTagMap["validate"].Prepend("omitempty", "")
+				return "*[" + length + "]" + elementGoType, elementTagMap
+			}
+			return "[" + length + "]" + elementGoType, elementTagMap
+		}
+		elementGoType, elementTagMap := getGoValidator(v.ArrayElements, 0, singularize(name), options)
 		if v.Arrays+v.Nulls < observations && v.Emptys == 0 {
 			jsonTag.Set(JSON_OMITEMPTY, "")
 		}
@@ -56,7 +122,7 @@ func GetGoValidator(v *Value, observations int, options *GoOption) (string, map[
 		return "[]" + elementGoType, elementTagMap
 	case distinctTypes == 1 && v.Bools > 0:
 		tagMap["validate"].Set("required", "")
-		if v.Bools < observations && v.Emptys == 0 {
+		if v.Bools < observations && v.Zeros == 0 {
 			jsonTag.Set(JSON_OMITEMPTY, "")
 		}
 		return "bool", tagMap
@@ -64,22 +130,32 @@ func GetGoValidator(v *Value, observations int, options *GoOption) (string, map[
 		return "*bool", tagMap
 	case distinctTypes == 1 && v.Float64s > 0:
 		tagMap["validate"].Set("required", "")
-		if v.Float64s < observations && v.Emptys == 0 {
+		setRangeRules(validatorTag, v, options)
+		if v.Float64s < observations && v.Zeros == 0 {
 			jsonTag.Set(JSON_OMITEMPTY, "")
 		}
 		return "float64", tagMap
 	case distinctTypes == 2 && v.Float64s > 0 && v.Nulls > 0:
+		setRangeRules(validatorTag, v, options)
 		return "*float64", tagMap
 	case distinctTypes == 1 && v.Ints > 0:
-		tagMap["validate"].Set("required", "")
-		if v.Ints < observations && v.Emptys == 0 {
+		intType := options.goIntType(v)
+		if intType != "json.Number" {
+			tagMap["validate"].Set("required", "")
+			setRangeRules(validatorTag, v, options)
+		}
+		if v.Ints < observations && v.Zeros == 0 {
 			jsonTag.Set(JSON_OMITEMPTY, "")
 		}
-		return options.intType, tagMap
+		return intType, tagMap
 	case distinctTypes == 2 && v.Ints > 0 && v.Nulls > 0:
-		return "*" + options.intType, tagMap
+		intType := options.goIntType(v)
+		if intType != "json.Number" {
+			setRangeRules(validatorTag, v, options)
+		}
+		return "*" + intType, tagMap
 	case distinctTypes == 2 && v.Float64s > 0 && v.Ints > 0:
-		omitEmpty := v.Float64s+v.Ints < observations && v.Emptys == 0
+		omitEmpty := v.Float64s+v.Ints < observations && v.Zeros == 0
 		if omitEmpty {
 			jsonTag.Set(JSON_OMITEMPTY, "")
 		}
@@ -88,12 +164,14 @@ func GetGoValidator(v *Value, observations int, options *GoOption) (string, map[
 			return "json.Number", tagMap
 		}
 		tagMap["validate"].Set("required", "")
+		setRangeRules(validatorTag, v, options)
 		return "float64", tagMap
 	case distinctTypes == 3 && v.Float64s > 0 && v.Ints > 0 && v.Nulls > 0:
 		if options.useJSONNumber {
 			options.Imports["encoding/json"] = struct{}{}
 			return "*json.Number", tagMap
 		}
+		setRangeRules(validatorTag, v, options)
 		return "*float64", tagMap
 	case distinctTypes == 1 && v.Objects > 0:
 		fallthrough
@@ -124,7 +202,7 @@ func GetGoValidator(v *Value, observations int, options *GoOption) (string, map[
 			}
 		}
 		if hasUnparseableProperties && !options.skipUnparseableProperties {
-			valueGoType, validatorMap := GetGoValidator(v.AllObjectProperties, 0, options)
+			valueGoType, validatorMap := getGoValidator(v.AllObjectProperties, 0, name+"Value", options)
 			if v.Objects+v.Nulls < observations {
 				jsonTag.Set(JSON_OMITEMPTY, "")
 			}
@@ -132,77 +210,78 @@ func GetGoValidator(v *Value, observations int, options *GoOption) (string, map[
 			tagMap["validate"].Prepend("required", "")
 			return "map[string]" + valueGoType, validatorMap
 		}
-		b := &bytes.Buffer{}
-		properties := maps.Keys(v.ObjectProperties)
-		sort.Strings(properties)
-		fmt.Fprintf(b, "struct {\n")
-		var unparseableProperties []string
-		for _, property := range properties {
-			if isUnparsableProperty(property) {
-				unparseableProperties = append(unparseableProperties, property)
-				continue
+		if keyKind := options.keyKind(v); keyKind != keysStatic {
+			keyGoType := "string"
+			if keyKind == keysInt {
+				keyGoType = options.goIntType(intKeys(v))
 			}
-			goType, tagMap := GetGoValidator(v.ObjectProperties[property], v.Objects, options)
-			tagMap["json"].Set(property, "")
-
-			var omitEmpty bool
-			switch {
-			case options.omitEmptyOption == OmitEmptyNever:
-				tagMap["json"].Unset(JSON_OMITEMPTY)
-			case options.omitEmptyOption == OmitEmptyAlways:
-				tagMap["json"].Set(JSON_OMITEMPTY, "")
-			case options.omitEmptyOption == OmitEmptyAuto:
-				// use return value
-			}
-
-			if omitEmpty {
+			valueGoType, validatorMap := getGoValidator(v.AllObjectProperties, 0, singularize(name), options)
+			if v.Objects+v.Nulls < observations {
 				jsonTag.Set(JSON_OMITEMPTY, "")
 			}
-
-			fmt.Fprintf(b, "%s %s `%s`\n", options.exportNameFunc(property), goType, getTagsString(tagMap))
+			validatorMap["validate"].Prepend("dive", "")
+			tagMap["validate"].Prepend("required", "")
+			return "map[" + keyGoType + "]" + valueGoType, validatorMap
 		}
-
-		for _, property := range unparseableProperties {
-			fmt.Fprintf(b, "// %q cannot be unmarshalled into a struct field by encoding/json.\n", property)
+		recursive := options.isRecursive(v)
+		var objectType string
+		if u := options.union(v); u != nil {
+			objectType = declareGoUnion(u, name, options)
+		} else {
+			objectType = getGoValidatorObject(v, name, options)
 		}
-		fmt.Fprintf(b, "}")
+
 		switch {
 		case observations == 0:
 			tagMap["validate"].Set("required", "")
-			return b.String(), tagMap
+			return objectType, tagMap
+		case v.Objects == observations && !recursive:
+			tagMap["validate"].Set("required", "")
+			return objectType, tagMap
 		case v.Objects == observations:
 			tagMap["validate"].Set("required", "")
-			return b.String(), tagMap
+			return "*" + objectType, tagMap
 		case v.Objects < observations && v.Nulls == 0:
+			// Absent objects are pointers, as in GetGoAst, so that the
+			// validator does not validate their zero value.
 			jsonTag.Set(JSON_OMITEMPTY, "")
-			return "*" + b.String(), tagMap
+			return "*" + objectType, tagMap
 		default:
 			if v.Objects+v.Nulls < observations {
 				jsonTag.Set(JSON_OMITEMPTY, "")
 			}
-			return "*" + b.String(), tagMap
+			return "*" + objectType, tagMap
 		}
-	case distinctTypes == 1 && v.Strings > 0 && v.Times == v.Strings:
-		safeTagName := getSafeTagName(v.TimestampFormat)
-		options.RegexpValidators[safeTagName] = regexp.MustCompile(`\d`).ReplaceAllString(v.TimestampFormat, `\d`)
+	case distinctTypes == 1 && v.Strings > 0 && ts != nil && ts.layout != "":
+		safeTagName := getSafeTagName(ts.layout)
+		options.RegexpValidators[safeTagName] = regexp.MustCompile(`\d`).ReplaceAllString(ts.layout, `\d`)
 		validatorTag.Set(safeTagName, "")
 		if v.Times < observations {
 			jsonTag.Set(JSON_OMITEMPTY, "")
 		}
 		return "string", tagMap
 	case distinctTypes == 1 && v.Strings > 0:
-		if v.Strings < observations && v.Emptys == 0 {
+		if tag, ok := formatValidatorTags[options.stringFormat(v)]; ok {
+			validatorTag.Set(tag, "")
+		}
+		if v.Strings < observations && v.Zeros == 0 {
 			jsonTag.Set(JSON_OMITEMPTY, "")
 		}
 		return "string", tagMap
-	case distinctTypes == 2 && v.Strings > 0 && v.Nulls > 0 && v.Times == v.Strings:
-		safeTagName := getSafeTagName(v.TimestampFormat)
-		options.RegexpValidators[safeTagName] = regexp.MustCompile(`\d`).ReplaceAllString(v.TimestampFormat, `\d`)
+	case distinctTypes == 2 && v.Strings > 0 && v.Nulls > 0 && ts != nil && ts.layout != "":
+		safeTagName := getSafeTagName(ts.layout)
+		options.RegexpValidators[safeTagName] = regexp.MustCompile(`\d`).ReplaceAllString(ts.layout, `\d`)
 		validatorTag.Set(safeTagName, "")
 		tagMap["validate"].Set("required", "")
 		return "*string", tagMap
 	case distinctTypes == 2 && v.Strings > 0 && v.Nulls > 0:
+		if tag, ok := formatValidatorTags[options.stringFormat(v)]; ok {
+			validatorTag.Set("omitempty", "")
+			validatorTag.Set(tag, "")
+		}
 		return "*string", tagMap
+	case sum != nil:
+		return options.declareGoSumType(v, sum), tagMap
 	default:
 		if v.Arrays+v.Bools+v.Float64s+v.Ints+v.Nulls+v.Objects+v.Strings < observations {
 			jsonTag.Set(JSON_OMITEMPTY, "")
@@ -211,10 +290,140 @@ func GetGoValidator(v *Value, observations int, options *GoOption) (string, map[
 	}
 }
 
-func getTagsString(tags map[string]*StructTag) string {
+// getGoValidatorObject returns the struct type of the object v, or the name
+// of the type declared for it if it is named or shares its shape with other
+// objects.
+func getGoValidatorObject(v *Value, name string, options *GoOption) string {
+	shape, shared := options.shapes.shapeOf(v)
+	if typeName, ok := options.shapeNames[shape]; ok {
+		return typeName
+	}
+	var typeSpec *ast.TypeSpec
+	switch {
+	case shared:
+		typeSpec = options.declareGoType(options.shapes.name(shape, name))
+		options.shapeNames[shape] = typeSpec.Name.Name
+	case options.namedTypes && name != "":
+		typeSpec = options.declareGoType(name)
+	}
+	if typeSpec != nil {
+		name = typeSpec.Name.Name
+	}
+
+	defer options.enclose(shape)()
+
+	b := &bytes.Buffer{}
+	properties := options.properties(shape)
+	fieldNames := options.goFieldNames(shape, properties)
+	fmt.Fprintf(b, "struct {\n")
+	var unparseableProperties []string
+	for _, property := range properties {
+		if isUnparsableProperty(property) {
+			unparseableProperties = append(unparseableProperties, property)
+			continue
+		}
+		override := options.override(shape, property)
+		if override.Skip {
+			continue
+		}
+
+		prop := shape.ObjectProperties[property]
+		var goType string
+		var tagMap map[string]*StructTag
+		if override.GoType == "" {
+			goType, tagMap = getGoStringifiedValidator(prop, shape.Objects, options)
+		}
+		switch {
+		case override.GoType != "":
+			goType = override.goTypeName(options)
+			tagMap = map[string]*StructTag{"json": newStructTag("json", ",", "="), "validate": newStructTag("validate", ",", "=")}
+		case goType != "":
+			// Stringified fields are never Nullable, as the ",string" option
+			// only applies to scalars.
+		case options.isNullable(prop) && !override.Required:
+			goType, tagMap = getGoValidator(prop, shape.Objects, name+fieldNames[property], options)
+			goType = options.goNullableType() + "[" + strings.TrimPrefix(goType, "*") + "]"
+			tagMap["validate"] = newStructTag("validate", ",", "=")
+			tagMap["json"].Unset(JSON_OMITEMPTY)
+			tagMap["json"].Set(JSON_OMITZERO, "")
+		default:
+			goType, tagMap = getGoValidator(prop, shape.Objects, name+fieldNames[property], options)
+		}
+		tagMap["json"].Prepend(property, "")
+
+		switch {
+		case override.Optional:
+			tagMap["json"].Unset(JSON_OMITEMPTY)
+			tagMap["json"].Set(JSON_OMITEMPTY, "")
+			tagMap["validate"].Unset("required")
+		case override.Required:
+			if override.GoType == "" {
+				goType = strings.TrimPrefix(goType, "*")
+			}
+			tagMap["json"].Unset(JSON_OMITEMPTY)
+			tagMap["validate"].Unset("required")
+			tagMap["validate"].Prepend("required", "")
+		case options.omitEmptyOption == OmitEmptyNever:
+			tagMap["json"].Unset(JSON_OMITEMPTY)
+		case options.omitEmptyOption == OmitEmptyAlways:
+			tagMap["json"].Unset(JSON_OMITEMPTY)
+			tagMap["json"].Set(JSON_OMITEMPTY, "")
+		case options.omitEmptyOption == OmitEmptyAuto:
+			// use return value
+		}
+		maps.Copy(tagMap, override.validatorTags())
+
+		fmt.Fprintf(b, "%s %s `%s`\n", fieldNames[property], goType, getTagsString(tagMap, options))
+	}
+
+	for _, property := range unparseableProperties {
+		fmt.Fprintf(b, "// %q cannot be unmarshalled into a struct field by encoding/json.\n", property)
+	}
+	fmt.Fprintf(b, "}")
+	objectType := b.String()
+	if typeSpec != nil {
+		structType, err := parser.ParseExpr(objectType)
+		if err != nil {
+			panic(fmt.Sprintf("oojson: generated invalid struct type %s: %v", objectType, err))
+		}
+		typeSpec.Type = structType
+		objectType = typeSpec.Name.Name
+	}
+	return objectType
+}
+
+// setRangeRules adds min and max rules for the observed range of the numbers
+// of v to validatorTag if range rules are enabled. Nil pointers are skipped,
+// and so are ranges that float64 cannot represent exactly.
+func setRangeRules(validatorTag *StructTag, v *Value, options *GoOption) {
+	if !options.rangeRules || v.UnsafeInts > 0 {
+		return
+	}
+	if v.Nulls > 0 {
+		validatorTag.Set("omitempty", "")
+	}
+	validatorTag.Set("min", strconv.FormatFloat(v.MinNumber, 'f', -1, 64))
+	validatorTag.Set("max", strconv.FormatFloat(v.MaxNumber, 'f', -1, 64))
+}
+
+// getTagsString returns the struct tags in tags. The "json" tag carries the
+// property name and is emitted once for each of options.structTagNames.
+func getTagsString(tags map[string]*StructTag, options *GoOption) string {
 	tagsString := []string{}
-	for _, tag := range tags {
-		s := tag.String()
+	for _, structTagName := range options.structTagNames {
+		tag := *tags["json"]
+		tag.Key = structTagName
+		if s := tag.String(); len(s) > 0 {
+			tagsString = append(tagsString, s)
+		}
+	}
+	keys := maps.Keys(tags)
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key == "json" {
+			continue
+		}
+		s := tags[key].String()
 		if len(s) < 1 {
 			continue
 		}
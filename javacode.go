@@ -3,7 +3,10 @@ package oojson
 import (
 	"bytes"
 	"fmt"
+	"math"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/iancoleman/strcase"
@@ -14,33 +17,55 @@ const (
 	JavaBool   = "Boolean"
 	JavaFloat  = "Float"
 	JavaInt    = "Integer"
+	JavaLong   = "Long"
+	JavaBigInt = "BigInteger"
 	JavaAny    = "Object"
 	JavaString = "String"
 	JavaTime   = "Date"
 )
 
 type JavaOption struct {
-	exportNameFunc ExportNameFunc
-	imports        map[string]struct{}
-	exportRenames  map[string]string
+	commonOption
+	imports       map[string]struct{}
+	sharedClasses map[string]string
 }
 
-func DefaultJavaOption() *JavaOption {
+// NewJavaOption returns the default JavaOption with opts applied, or an error if
+// any of opts is invalid.
+func NewJavaOption(opts ...Option) (*JavaOption, error) {
 	opt := &JavaOption{
 		imports: make(map[string]struct{}),
 	}
 
-	opt.exportNameFunc = func(name string) string {
-		if rename, ok := opt.exportRenames[name]; ok {
-			return rename
-		}
-		return strcase.ToLowerCamel(name)
+	opt.exportNameFunc = strcase.ToLowerCamel
+	if err := applyOptions(opt, opts); err != nil {
+		return nil, err
+	}
+	return opt, nil
+}
+
+// DefaultJavaOption is like NewJavaOption but panics if any of opts is invalid.
+func DefaultJavaOption(opts ...Option) *JavaOption {
+	opt, err := NewJavaOption(opts...)
+	if err != nil {
+		panic(err)
 	}
 	return opt
 }
 
-// goType returns the Go type of v.
+// GetJavaType returns the Java type of v, which is named name if it is an
+// object, and the code of the class it declares.
 func GetJavaType(v *Value, name string, indent string, options *JavaOption) (string, string) {
+	options.unifyShapes(v)
+	options.resolveOverrides(v)
+	options.sharedClasses = make(map[string]string)
+	return getJavaType(v, name, indent, true, options)
+}
+
+// getJavaType is GetJavaType for a value that is the root class if root is
+// true. Shared classes are declared in the root class so that all classes can
+// refer to them.
+func getJavaType(v *Value, name string, indent string, root bool, options *JavaOption) (string, string) {
 	// Determine the number of distinct types observed.
 	distinctTypes := 0
 	if v.Arrays > 0 {
@@ -66,13 +91,21 @@ func GetJavaType(v *Value, name string, indent string, options *JavaOption) (str
 	}
 
 	subClasses := map[string]string{}
+	e := options.enum(v)
+	ts := options.timestamp(v)
+	sum := options.sumType(v)
 
 	// Based on the observed distinct types, find the most specific Go type.
 	switch {
+	case ts != nil:
+		return getJavaTimeType(ts, options), ""
+	case e != nil:
+		options.imports["com.fasterxml.jackson.annotation.JsonValue"] = struct{}{}
+		return name, getJavaEnum(e, name, indent)
 	case distinctTypes == 1 && v.Arrays > 0:
 		fallthrough
 	case distinctTypes == 2 && v.Arrays > 0 && v.Nulls > 0:
-		elementJavaType, customDefinition := GetJavaType(v.ArrayElements, name, indent, options)
+		elementJavaType, customDefinition := getJavaType(v.ArrayElements, name, indent, root, options)
 		options.imports["java.util.List"] = struct{}{}
 		if len(customDefinition) > 0 {
 			subClasses[elementJavaType] = customDefinition
@@ -87,9 +120,9 @@ func GetJavaType(v *Value, name string, indent string, options *JavaOption) (str
 	case distinctTypes == 2 && v.Float64s > 0 && v.Nulls > 0:
 		return JavaFloat, ""
 	case distinctTypes == 1 && v.Ints > 0:
-		return JavaInt, ""
+		return getJavaIntType(v, options), ""
 	case distinctTypes == 2 && v.Ints > 0 && v.Nulls > 0:
-		return JavaInt, ""
+		return getJavaIntType(v, options), ""
 	case distinctTypes == 2 && v.Float64s > 0 && v.Ints > 0:
 		return JavaFloat, ""
 	case distinctTypes == 3 && v.Float64s > 0 && v.Ints > 0 && v.Nulls > 0:
@@ -100,9 +133,29 @@ func GetJavaType(v *Value, name string, indent string, options *JavaOption) (str
 		if len(v.ObjectProperties) == 0 {
 			return JavaAny, ""
 		}
+		if u := options.union(v); u != nil {
+			return getJavaUnion(u, name, indent, root, options)
+		}
+		if keyKind := options.keyKind(v); keyKind != keysStatic {
+			keyJavaType := JavaString
+			if keyKind == keysInt {
+				keyJavaType = JavaLong
+			}
+			valueJavaType, customDefinition := getJavaType(v.AllObjectProperties, singularize(name), indent, root, options)
+			options.imports["java.util.Map"] = struct{}{}
+			return fmt.Sprintf("Map<%s, %s>", keyJavaType, valueJavaType), customDefinition
+		}
+		shape, shared := options.shapes.shapeOf(v)
+		if typeName, ok := options.shapeNames[shape]; ok {
+			return typeName, ""
+		}
+		if shared {
+			name = options.shapes.name(shape, name)
+			options.shapeNames[shape] = name
+		}
 		b := &bytes.Buffer{}
-		properties := maps.Keys(v.ObjectProperties)
-		sort.Strings(properties)
+		properties := options.properties(shape)
+		fieldNames := options.fieldNames(shape, properties, options.exportName)
 		fmt.Fprintf(b, "class %v {\n", name)
 		var unparseableProperties []string
 		for _, property := range properties {
@@ -111,33 +164,180 @@ func GetJavaType(v *Value, name string, indent string, options *JavaOption) (str
 				continue
 			}
 
-			subClassType, customCode := GetJavaType(v.ObjectProperties[property], strcase.ToCamel(property), indent, options)
-			if len(customCode) > 0 {
-				subClasses[subClassType] = customCode
+			override := options.override(shape, property)
+			if override.Skip {
+				continue
+			}
+			fieldName := fieldNames[property]
+			if fieldName != options.exportName(property) {
+				options.imports["com.fasterxml.jackson.annotation.JsonProperty"] = struct{}{}
+				fmt.Fprintf(b, "%v@JsonProperty(%q)\n", indent, property)
 			}
-			fmt.Fprintf(b, "%vprivate %s %s;\n", indent, subClassType, strcase.ToLowerCamel(property))
+			prop := shape.ObjectProperties[property]
+			var javaType string
+			if override.JavaType != "" {
+				javaType = override.javaTypeName(options)
+			} else if javaType = getJavaStringifiedType(prop, options); javaType != "" {
+				options.imports["com.fasterxml.jackson.annotation.JsonFormat"] = struct{}{}
+				fmt.Fprintf(b, "%v@JsonFormat(shape = JsonFormat.Shape.STRING)\n", indent)
+			} else {
+				var customCode string
+				javaType, customCode = getJavaType(prop, strcase.ToCamel(property), indent, false, options)
+				if len(customCode) > 0 {
+					subClasses[javaType] = customCode
+				}
+				if ts := options.timestamp(prop); ts != nil && ts.kind == timeUnixMilli {
+					// Jackson reads and writes integer Instants as seconds
+					// unless the nanoseconds features are disabled.
+					options.imports["com.fasterxml.jackson.annotation.JsonFormat"] = struct{}{}
+					fmt.Fprintf(b, "%v@JsonFormat(without = {JsonFormat.Feature.READ_DATE_TIMESTAMPS_AS_NANOSECONDS, JsonFormat.Feature.WRITE_DATE_TIMESTAMPS_AS_NANOSECONDS})\n", indent)
+				}
+			}
+			writeJavaField(b, override.presence(prop), javaType, fieldName, indent, options)
 		}
 		for _, property := range unparseableProperties {
 			fmt.Fprintf(b, "// %q cannot be unmarshalled into a struct field by encoding/json.\n", property)
 		}
 
-		for _, code := range subClasses {
-			fmt.Fprintf(b, "%v@Data\n%vpublic static %s\n", indent, indent, strings.ReplaceAll(code, "\n", "\n"+indent))
+		if root {
+			for sharedName, code := range options.sharedClasses {
+				subClasses[sharedName] = code
+			}
 		}
+		writeJavaSubClasses(b, subClasses, indent)
 		fmt.Fprintf(b, "}")
 
+		if shared && !root {
+			options.sharedClasses[name] = b.String()
+			return name, ""
+		}
 		return name, b.String()
-	case distinctTypes == 1 && v.Strings > 0 && v.Times == v.Strings:
-		options.imports["java.util.Date"] = struct{}{}
-		return JavaTime, ""
 	case distinctTypes == 1 && v.Strings > 0:
-		return JavaString, ""
-	case distinctTypes == 2 && v.Strings > 0 && v.Nulls > 0 && v.Times == v.Strings:
-		options.imports["java.util.Date"] = struct{}{}
-		return JavaTime, ""
+		return getJavaStringType(v, options), ""
 	case distinctTypes == 2 && v.Strings > 0 && v.Nulls > 0:
-		return JavaString, ""
+		return getJavaStringType(v, options), ""
+	case sum != nil:
+		return getJavaSumType(v, sum, indent, options), ""
 	default:
 		return JavaAny, ""
 	}
 }
+
+// writeJavaField writes the declaration of a field of type javaType for the
+// property v to b. Fields of properties that were null are @Nullable, and
+// fields of properties that were absent are Optional. If a property was both,
+// a null field means it was absent and an empty Optional that it was null.
+func writeJavaField(b *bytes.Buffer, v *Value, javaType string, fieldName string, indent string, options *JavaOption) {
+	if v.Nulls > 0 {
+		options.imports["javax.annotation.Nullable"] = struct{}{}
+		fmt.Fprintf(b, "%v@Nullable\n", indent)
+	}
+	switch {
+	case v.Absents > 0 && v.Nulls > 0:
+		options.imports["java.util.Optional"] = struct{}{}
+		fmt.Fprintf(b, "%vprivate Optional<%s> %s;\n", indent, javaType, fieldName)
+	case v.Absents > 0:
+		options.imports["java.util.Optional"] = struct{}{}
+		fmt.Fprintf(b, "%vprivate Optional<%s> %s = Optional.empty();\n", indent, javaType, fieldName)
+	default:
+		fmt.Fprintf(b, "%vprivate %s %s;\n", indent, javaType, fieldName)
+	}
+}
+
+// writeJavaSubClasses writes the code of the nested classes, enums, unions
+// and sum types subClasses to b, sorted by name.
+func writeJavaSubClasses(b *bytes.Buffer, subClasses map[string]string, indent string) {
+	names := maps.Keys(subClasses)
+	sort.Strings(names)
+	for _, name := range names {
+		code := subClasses[name]
+		switch {
+		case strings.HasPrefix(code, "enum "):
+			fmt.Fprintf(b, "%vpublic %s\n", indent, strings.ReplaceAll(code, "\n", "\n"+indent))
+		case strings.HasPrefix(code, "@JsonTypeInfo"):
+			code = strings.Replace(code, "\nabstract class ", "\npublic static abstract class ", 1)
+			fmt.Fprintf(b, "%v%s\n", indent, strings.ReplaceAll(code, "\n", "\n"+indent))
+		case strings.HasPrefix(code, "@JsonDeserialize"):
+			code = strings.Replace(code, "\nclass ", "\npublic static class ", 1)
+			fmt.Fprintf(b, "%v%s\n", indent, strings.ReplaceAll(code, "\n", "\n"+indent))
+		default:
+			fmt.Fprintf(b, "%v@Data\n%vpublic static %s\n", indent, indent, strings.ReplaceAll(code, "\n", "\n"+indent))
+		}
+	}
+}
+
+// javaFormatTypes are the Java classes of the semantic string formats that
+// have one.
+var javaFormatTypes = map[string]string{
+	FormatUUID: "java.util.UUID",
+	FormatURI:  "java.net.URI",
+}
+
+// getJavaStringType returns the Java type of the strings observed for v.
+func getJavaStringType(v *Value, options *JavaOption) string {
+	class, ok := javaFormatTypes[options.stringFormat(v)]
+	if !ok {
+		return JavaString
+	}
+	options.imports[class] = struct{}{}
+	return class[strings.LastIndexByte(class, '.')+1:]
+}
+
+// getJavaIntType returns the smallest Java integer type that holds the
+// integers observed for v.
+func getJavaIntType(v *Value, options *JavaOption) string {
+	switch {
+	case v.Uint64s > 0 || v.BigInts > 0:
+		options.imports["java.math.BigInteger"] = struct{}{}
+		return JavaBigInt
+	case v.MinNumber < math.MinInt32 || v.MaxNumber > math.MaxInt32:
+		return JavaLong
+	default:
+		return JavaInt
+	}
+}
+
+// getJavaEnum returns the code of an enum named name with a constant for each
+// value of e. Jackson serializes the constants as the observed values.
+func getJavaEnum(e *enum, name string, indent string) string {
+	valueType := JavaString
+	if e.strings == nil {
+		valueType = JavaInt
+	}
+	var constants []string
+	used := make(map[string]bool)
+	addConstant := func(value, literal string) {
+		constant := getJavaEnumConstant(value)
+		for i := 2; used[constant]; i++ {
+			constant = fmt.Sprintf("%s_%d", getJavaEnumConstant(value), i)
+		}
+		used[constant] = true
+		constants = append(constants, fmt.Sprintf("%v(%s)", constant, literal))
+	}
+	for _, value := range e.strings {
+		addConstant(value, strconv.Quote(value))
+	}
+	for _, value := range e.ints {
+		addConstant(strconv.FormatInt(value, 10), strconv.FormatInt(value, 10))
+	}
+
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "enum %s {\n", name)
+	fmt.Fprintf(b, "%v%s;\n", indent, strings.Join(constants, ",\n"+indent))
+	fmt.Fprintf(b, "%vprivate final %s value;\n", indent, valueType)
+	fmt.Fprintf(b, "%v%s(%s value) {\n%v%vthis.value = value;\n%v}\n", indent, name, valueType, indent, indent, indent)
+	fmt.Fprintf(b, "%v@JsonValue\n", indent)
+	fmt.Fprintf(b, "%vpublic %s getValue() {\n%v%vreturn value;\n%v}\n", indent, valueType, indent, indent, indent)
+	fmt.Fprintf(b, "}")
+	return b.String()
+}
+
+// getJavaEnumConstant returns the name of the enum constant for value.
+func getJavaEnumConstant(value string) string {
+	constant := strcase.ToScreamingSnake(value)
+	constant = regexp.MustCompile(`[^A-Z0-9_]+`).ReplaceAllString(constant, "_")
+	if constant == "" || constant[0] >= '0' && constant[0] <= '9' || constant[0] == '-' {
+		constant = "VALUE_" + constant
+	}
+	return constant
+}
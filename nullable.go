@@ -0,0 +1,105 @@
+package oojson
+
+import (
+	"fmt"
+	"go/ast"
+)
+
+// goNullableSource is the declaration of the Nullable type. %[1]s is its
+// name.
+const goNullableSource = `// %[1]s is a JSON value that can be absent, null or set.
+type %[1]s[T any] struct {
+	Value T
+	set   bool
+	null  bool
+}
+
+// IsSet returns true if the value was present, including as null.
+func (n %[1]s[T]) IsSet() bool {
+	return n.set
+}
+
+// IsNull returns true if the value was null.
+func (n %[1]s[T]) IsNull() bool {
+	return n.null
+}
+
+// IsZero returns true if the value was absent, so that fields tagged
+// omitzero are omitted when marshalled.
+func (n %[1]s[T]) IsZero() bool {
+	return !n.set
+}
+
+// Set sets the value to value.
+func (n *%[1]s[T]) Set(value T) {
+	*n = %[1]s[T]{Value: value, set: true}
+}
+
+// SetNull sets the value to null.
+func (n *%[1]s[T]) SetNull() {
+	*n = %[1]s[T]{set: true, null: true}
+}
+
+// UnmarshalJSON decodes data into n, which is only called for present values.
+func (n *%[1]s[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		n.SetNull()
+		return nil
+	}
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	n.Set(value)
+	return nil
+}
+
+// MarshalJSON encodes n, as null if it is null or absent. Absent fields are
+// only omitted if they are tagged omitzero.
+func (n %[1]s[T]) MarshalJSON() ([]byte, error) {
+	if !n.set || n.null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.Value)
+}
+`
+
+// WithNullableType sets whether properties that were both absent and null
+// have a generated Nullable[T] type instead of a pointer, so that absent and
+// null values can be told apart. Nullable fields are tagged omitzero, which
+// requires Go 1.24 to omit absent values when marshalling.
+func WithNullableType(enabled bool) Option {
+	return func(target optionTarget) error {
+		opt, err := goOptionTarget("WithNullableType", target)
+		if err != nil {
+			return err
+		}
+		opt.nullableType = enabled
+		return nil
+	}
+}
+
+// isNullable returns true if the property v has a Nullable type.
+func (o *GoOption) isNullable(v *Value) bool {
+	return o.nullableType && v.Absents > 0 && v.Nulls > 0
+}
+
+// goNullableType returns the name of the Nullable type, which is declared the
+// first time it is used.
+func (o *GoOption) goNullableType() string {
+	if o.nullableTypeName == "" {
+		o.nullableTypeName = o.reserveGoTypeName("Nullable")
+		o.Imports["encoding/json"] = struct{}{}
+		o.declareGoSource(fmt.Sprintf(goNullableSource, o.nullableTypeName))
+	}
+	return o.nullableTypeName
+}
+
+// getGoNullableAst returns the Nullable type of values of goType, which is
+// a pointer type.
+func getGoNullableAst(goType ast.Expr, options *GoOption) ast.Expr {
+	if star, ok := goType.(*ast.StarExpr); ok {
+		goType = star.X
+	}
+	return &ast.IndexExpr{X: ast.NewIdent(options.goNullableType()), Index: goType}
+}
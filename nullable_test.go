@@ -0,0 +1,30 @@
+package oojson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNullableRoundTrip(t *testing.T) {
+	data := `{"items":[{"a":"x"},{"a":null},{}]}`
+	output := runGoFile(t, data, `import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var root Root
+	if err := json.Unmarshal([]byte(`+"`"+data+"`"+`), &root); err != nil {
+		panic(err)
+	}
+	encoded, err := json.Marshal(root)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(encoded))
+}
+`, WithNullableType(true))
+	if strings.TrimSpace(output) != data {
+		t.Errorf("expected %s, got %s", data, output)
+	}
+}
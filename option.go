@@ -0,0 +1,369 @@
+package oojson
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// An Option configures a GoOption, TsOption or JavaOption.
+type Option func(target optionTarget) error
+
+// An optionTarget is an option struct that Options can be applied to.
+type optionTarget interface {
+	common() *commonOption
+	validate() error
+}
+
+// commonOption holds the settings shared by all generators.
+type commonOption struct {
+	exportNameFunc      ExportNameFunc
+	exportRenames       map[string]string
+	similarityThreshold float64
+	recursiveThreshold  float64
+	maxEnumValues       int
+	minDynamicKeys      int
+	tuples              bool
+	unions              bool
+	sumTypes            bool
+	stringifiedTypes    bool
+	fieldOrder          FieldOrder
+	overrides           []overrideSelector
+	overridden          map[*Value]map[string]Override // overrides of the properties of each object
+	epochTimestamps     bool
+	timestampFormats    []string // layouts that become time types, nil for all
+	formats             *FormatRegistry
+	shapes              *Shapes
+	shapeNames          map[*Value]string // names of the shared types generated so far
+}
+
+func (c *commonOption) common() *commonOption {
+	return c
+}
+
+// validate returns an error if options applied to c contradict each other.
+func (c *commonOption) validate() error {
+	return nil
+}
+
+// exportName returns the name of property in generated code. Renames take
+// precedence over the export name function.
+func (c *commonOption) exportName(property string) string {
+	if rename, ok := c.exportRenames[property]; ok {
+		return rename
+	}
+	return c.exportNameFunc(property)
+}
+
+// fieldNames returns the field name of each of the properties of the object
+// v, which is the rename of its override or name(property). Renamed fields are
+// named first, and properties that map to the same name are disambiguated
+// with numeric suffixes in sorted property order, so the first property keeps
+// the plain name.
+func (c *commonOption) fieldNames(v *Value, properties []string, name func(string) string) map[string]string {
+	sorted := append([]string(nil), properties...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		iRenamed, jRenamed := c.override(v, sorted[i]).Rename != "", c.override(v, sorted[j]).Rename != ""
+		if iRenamed != jRenamed {
+			return iRenamed
+		}
+		return sorted[i] < sorted[j]
+	})
+	names := make(map[string]string, len(sorted))
+	used := make(map[string]bool, len(sorted))
+	for _, property := range sorted {
+		base := c.override(v, property).Rename
+		if base == "" {
+			base = name(property)
+		}
+		fieldName := base
+		for i := 2; used[fieldName]; i++ {
+			fieldName = base + strconv.Itoa(i)
+		}
+		used[fieldName] = true
+		names[property] = fieldName
+	}
+	return names
+}
+
+// unifyShapes finds the shared shapes in the root value v if shared or
+// recursive types are enabled.
+func (c *commonOption) unifyShapes(v *Value) {
+	switch {
+	case c.similarityThreshold > 0:
+		c.shapes = unifyShapes(v, &Shapes{threshold: c.similarityThreshold, union: c.union})
+	case c.recursiveThreshold > 0:
+		c.shapes = unifyShapes(v, &Shapes{threshold: c.recursiveThreshold, recursiveOnly: true, union: c.union})
+	}
+	c.shapeNames = make(map[*Value]string)
+}
+
+// applyOptions applies opts to target in order and returns the first error,
+// or an error if the options contradict each other.
+func applyOptions(target optionTarget, opts []Option) error {
+	for _, opt := range opts {
+		if err := opt(target); err != nil {
+			return err
+		}
+	}
+	return target.validate()
+}
+
+// goOptionTarget returns target as a *GoOption, or an error naming the option
+// if target is an option for another language.
+func goOptionTarget(name string, target optionTarget) (*GoOption, error) {
+	opt, ok := target.(*GoOption)
+	if !ok {
+		return nil, fmt.Errorf("oojson: %s does not apply to %T", name, target)
+	}
+	return opt, nil
+}
+
+var goIntTypes = map[string]bool{
+	"int":    true,
+	"int8":   true,
+	"int16":  true,
+	"int32":  true,
+	"int64":  true,
+	"uint":   true,
+	"uint8":  true,
+	"uint16": true,
+	"uint32": true,
+	"uint64": true,
+}
+
+// WithIntType sets the Go type used for integers.
+func WithIntType(intType string) Option {
+	return func(target optionTarget) error {
+		opt, err := goOptionTarget("WithIntType", target)
+		if err != nil {
+			return err
+		}
+		if !goIntTypes[intType] {
+			return fmt.Errorf("oojson: %q is not a Go integer type", intType)
+		}
+		opt.intType = intType
+		opt.intTypeSet = true
+		return nil
+	}
+}
+
+// WithAutoIntType sets whether the Go type of integers is the smallest of
+// int8, int16, int32 and int64 that holds the observed range. It cannot be
+// enabled together with WithIntType.
+func WithAutoIntType(autoIntType bool) Option {
+	return func(target optionTarget) error {
+		opt, err := goOptionTarget("WithAutoIntType", target)
+		if err != nil {
+			return err
+		}
+		opt.autoIntType = autoIntType
+		return nil
+	}
+}
+
+// WithRangeRules sets whether GetGoValidator adds min and max rules for the
+// observed range of numbers.
+func WithRangeRules(rangeRules bool) Option {
+	return func(target optionTarget) error {
+		opt, err := goOptionTarget("WithRangeRules", target)
+		if err != nil {
+			return err
+		}
+		opt.rangeRules = rangeRules
+		return nil
+	}
+}
+
+// WithOmitEmpty sets how omitempty is added to struct tags.
+func WithOmitEmpty(omitEmptyOption OmitEmptyOption) Option {
+	return func(target optionTarget) error {
+		opt, err := goOptionTarget("WithOmitEmpty", target)
+		if err != nil {
+			return err
+		}
+		if omitEmptyOption < OmitEmptyNever || omitEmptyOption > OmitEmptyAuto {
+			return fmt.Errorf("oojson: invalid omitempty option %d", omitEmptyOption)
+		}
+		opt.omitEmptyOption = omitEmptyOption
+		return nil
+	}
+}
+
+// WithStructTags sets the keys of the struct tags that carry property names,
+// e.g. "json" and "yaml".
+func WithStructTags(structTagNames ...string) Option {
+	return func(target optionTarget) error {
+		opt, err := goOptionTarget("WithStructTags", target)
+		if err != nil {
+			return err
+		}
+		if len(structTagNames) == 0 {
+			return fmt.Errorf("oojson: at least one struct tag is required")
+		}
+		seen := make(map[string]bool)
+		for _, name := range structTagNames {
+			if name == "" || strings.ContainsAny(name, " `\":") {
+				return fmt.Errorf("oojson: invalid struct tag key %q", name)
+			}
+			if name == "validate" {
+				return fmt.Errorf("oojson: struct tag key %q is reserved for the validator", name)
+			}
+			if seen[name] {
+				return fmt.Errorf("oojson: duplicate struct tag key %q", name)
+			}
+			seen[name] = true
+		}
+		opt.structTagNames = append([]string(nil), structTagNames...)
+		return nil
+	}
+}
+
+// WithJSONNumber sets whether json.Number is used for properties that hold
+// both integers and floats.
+func WithJSONNumber(useJSONNumber bool) Option {
+	return func(target optionTarget) error {
+		opt, err := goOptionTarget("WithJSONNumber", target)
+		if err != nil {
+			return err
+		}
+		opt.useJSONNumber = useJSONNumber
+		return nil
+	}
+}
+
+// WithSkipUnparseableProperties sets whether objects with properties that
+// cannot be struct fields are still generated as structs. If false, such
+// objects become maps.
+func WithSkipUnparseableProperties(skip bool) Option {
+	return func(target optionTarget) error {
+		opt, err := goOptionTarget("WithSkipUnparseableProperties", target)
+		if err != nil {
+			return err
+		}
+		opt.skipUnparseableProperties = skip
+		return nil
+	}
+}
+
+// WithNamedTypes sets whether nested objects are declared as named types in
+// GoOption.Decls instead of anonymous structs. Types are named after their
+// property path, e.g. properties[].meta becomes PropertyMeta.
+func WithNamedTypes(namedTypes bool) Option {
+	return func(target optionTarget) error {
+		opt, err := goOptionTarget("WithNamedTypes", target)
+		if err != nil {
+			return err
+		}
+		opt.namedTypes = namedTypes
+		return nil
+	}
+}
+
+// WithTimestampFormats restricts the timestamp layouts that generators turn
+// into time types. Strings observed with any other layout stay strings.
+func WithTimestampFormats(timestampFormats ...string) Option {
+	return func(target optionTarget) error {
+		for _, format := range timestampFormats {
+			if format == "" {
+				return fmt.Errorf("oojson: empty timestamp format")
+			}
+		}
+		target.common().timestampFormats = append([]string{}, timestampFormats...)
+		return nil
+	}
+}
+
+// WithRenames sets explicit names for properties, overriding the export name
+// function.
+func WithRenames(renames map[string]string) Option {
+	return func(target optionTarget) error {
+		_, isGo := target.(*GoOption)
+		properties := make(map[string]string)
+		for property, rename := range renames {
+			switch {
+			case !token.IsIdentifier(rename):
+				return fmt.Errorf("oojson: rename of %q to %q is not a valid identifier", property, rename)
+			case isGo && !token.IsExported(rename):
+				return fmt.Errorf("oojson: rename of %q to %q is not exported", property, rename)
+			}
+			if other, ok := properties[rename]; ok {
+				return fmt.Errorf("oojson: %q and %q are both renamed to %q", other, property, rename)
+			}
+			properties[rename] = property
+		}
+		c := target.common()
+		if c.exportRenames == nil {
+			c.exportRenames = make(map[string]string)
+		}
+		for property, rename := range renames {
+			c.exportRenames[property] = rename
+		}
+		return nil
+	}
+}
+
+// WithSharedTypes sets whether objects with compatible shapes share a single
+// type. Objects whose property names have a Jaccard similarity of at least
+// threshold are unified; a threshold of 1 only unifies identical shapes.
+func WithSharedTypes(threshold float64) Option {
+	return func(target optionTarget) error {
+		if threshold <= 0 || threshold > 1 {
+			return fmt.Errorf("oojson: similarity threshold %v is not in (0, 1]", threshold)
+		}
+		target.common().similarityThreshold = threshold
+		return nil
+	}
+}
+
+// WithRecursiveTypes sets whether objects that are compatible with one of
+// their ancestors, such as the replies in a comment thread, are generated as
+// a self-referential type. threshold is the similarity threshold described in
+// WithSharedTypes. Shared types imply recursive types.
+func WithRecursiveTypes(threshold float64) Option {
+	return func(target optionTarget) error {
+		if threshold <= 0 || threshold > 1 {
+			return fmt.Errorf("oojson: similarity threshold %v is not in (0, 1]", threshold)
+		}
+		target.common().recursiveThreshold = threshold
+		return nil
+	}
+}
+
+// WithStringFormats sets whether strings whose semantic format was detected
+// by an Observer are generated with format-specific types, such as uuid.UUID
+// or java.util.UUID, and validator tags. The order of formats in registry
+// decides between formats that all strings match; nil means the default
+// registry.
+func WithStringFormats(registry *FormatRegistry) Option {
+	return func(target optionTarget) error {
+		if registry == nil {
+			registry = NewFormatRegistry()
+		}
+		target.common().formats = registry
+		return nil
+	}
+}
+
+// stringFormat returns the semantic format of the strings of v, or the empty
+// string if string formats are disabled or there is none.
+func (c *commonOption) stringFormat(v *Value) string {
+	if c.formats == nil {
+		return ""
+	}
+	return c.formats.format(v)
+}
+
+// WithExportNameFunc sets the function that names properties that are not
+// renamed.
+func WithExportNameFunc(exportNameFunc ExportNameFunc) Option {
+	return func(target optionTarget) error {
+		if exportNameFunc == nil {
+			return fmt.Errorf("oojson: nil export name function")
+		}
+		target.common().exportNameFunc = exportNameFunc
+		return nil
+	}
+}
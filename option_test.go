@@ -0,0 +1,50 @@
+package oojson
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimestampFormatsApplyToAllGenerators(t *testing.T) {
+	observer := NewObserver()
+	observer.Formats.Register("stamp", regexp.MustCompile(`^\d{4}-\d\d-\d\d \d\d:\d\d:\d\d$`).MatchString)
+	v, err := observer.ObserveJSON(nil, []byte(`{"at":"2024-01-02 03:04:05"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		layout string
+		goType string
+		tsType string
+		java   string
+	}{
+		{time.RFC3339, "At string", "at: Stamp;", "String at;"},
+		{time.DateTime, "At DateTime", "at: string;", "LocalDateTime at;"},
+	}
+	for _, test := range tests {
+		opts := []Option{WithTimestampFormats(test.layout), WithStringFormats(observer.Formats)}
+		if goType := GetGoType(v, DefaultGoOption(opts...)); !strings.Contains(goType, test.goType) {
+			t.Errorf("%s: expected %s in Go, got %s", test.layout, test.goType, goType)
+		}
+		// Strings that are not timestamps get the branded type of their format.
+		_, tsCode := GetTsType(v, "Root", "  ", DefaultTsOption(opts...))
+		if !strings.Contains(tsCode, test.tsType) {
+			t.Errorf("%s: expected %s in TypeScript, got\n%s", test.layout, test.tsType, tsCode)
+		}
+		_, javaCode := GetJavaType(v, "Root", "  ", DefaultJavaOption(opts...))
+		if !strings.Contains(javaCode, test.java) {
+			t.Errorf("%s: expected %s in Java, got\n%s", test.layout, test.java, javaCode)
+		}
+	}
+}
+
+func TestContradictoryOptions(t *testing.T) {
+	if _, err := NewGoOption(WithIntType("int64"), WithAutoIntType(true)); err == nil {
+		t.Error("expected an error for WithIntType with WithAutoIntType(true)")
+	}
+	if _, err := NewGoOption(WithIntType("int64"), WithAutoIntType(false)); err != nil {
+		t.Error(err)
+	}
+}
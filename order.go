@@ -0,0 +1,91 @@
+package oojson
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/exp/maps"
+)
+
+// A FieldOrder is the order of the fields of generated types.
+type FieldOrder int
+
+// Field orders.
+const (
+	FieldOrderAlphabetical  FieldOrder = iota // sorted by property name
+	FieldOrderSource                          // in the order the properties were first observed
+	FieldOrderRequiredFirst                   // properties that were never absent or null first, then the others, each sorted by name
+)
+
+// WithFieldOrder sets the order of the fields of generated types. The default
+// is FieldOrderAlphabetical.
+func WithFieldOrder(order FieldOrder) Option {
+	return func(target optionTarget) error {
+		if order < FieldOrderAlphabetical || order > FieldOrderRequiredFirst {
+			return fmt.Errorf("oojson: invalid field order %d", order)
+		}
+		target.common().fieldOrder = order
+		return nil
+	}
+}
+
+// properties returns the properties of the object v in the field order.
+// Properties missing from v.PropertyOrder, e.g. in objects that were observed
+// as maps, follow the others in source order.
+func (c *commonOption) properties(v *Value) []string {
+	sorted := maps.Keys(v.ObjectProperties)
+	sort.Strings(sorted)
+	switch c.fieldOrder {
+	case FieldOrderSource:
+		properties := make([]string, 0, len(sorted))
+		seen := make(map[string]bool, len(sorted))
+		for _, property := range v.PropertyOrder {
+			if _, ok := v.ObjectProperties[property]; ok && !seen[property] {
+				properties = append(properties, property)
+				seen[property] = true
+			}
+		}
+		for _, property := range sorted {
+			if !seen[property] {
+				properties = append(properties, property)
+			}
+		}
+		return properties
+	case FieldOrderRequiredFirst:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return isRequired(v.ObjectProperties[sorted[i]]) && !isRequired(v.ObjectProperties[sorted[j]])
+		})
+		return sorted
+	default:
+		return sorted
+	}
+}
+
+// isRequired returns true if the property v was present and not null in every
+// object.
+func isRequired(v *Value) bool {
+	return v.Absents == 0 && v.Nulls == 0
+}
+
+// observePropertyOrder appends the properties in keys that v has not seen
+// before to v.PropertyOrder.
+func observePropertyOrder(v *Value, keys []string) {
+	if len(v.PropertyOrder) == len(v.ObjectProperties) {
+		for _, key := range keys {
+			if _, ok := v.ObjectProperties[key]; !ok {
+				v.PropertyOrder = append(v.PropertyOrder, key)
+			}
+		}
+		return
+	}
+	seen := make(map[string]bool, len(v.PropertyOrder))
+	for _, property := range v.PropertyOrder {
+		seen[property] = true
+	}
+	for _, key := range keys {
+		if !seen[key] {
+			v.PropertyOrder = append(v.PropertyOrder, key)
+			seen[key] = true
+		}
+	}
+}
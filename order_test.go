@@ -0,0 +1,75 @@
+package oojson
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// orderSample has properties out of alphabetical order, some absent or null.
+const orderSample = `[
+	{"zeta":1,"alpha":"a","mid":null,"events":[{"type":"click","x":1},{"type":"view","page":"/"}],"id":"1","status":"on"},
+	{"zeta":2,"mid":true,"events":[],"id":2,"status":"off","billing":{"city":"x"},"shipping":{"city":"y"}},
+	{"zeta":3,"alpha":"b","mid":false,"id":"3","status":"on","tags":{"u_1":{"n":1},"u_2":{"n":2}}}
+]`
+
+// generateAll returns the Go, TypeScript and Java code generated for
+// orderSample, observed afresh, with opts.
+func generateAll(t *testing.T, opts ...Option) []byte {
+	t.Helper()
+	v := observeJSON(t, orderSample)
+	goCode, err := GenerateGoFile(v, GoFileOptions{Package: "model", Options: DefaultGoOption(append(opts, WithNamedTypes(true))...)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, tsCode := GetTsType(v, "Root", "  ", DefaultTsOption(opts...))
+	_, javaCode := GetJavaType(v, "Root", "  ", DefaultJavaOption(opts...))
+	return bytes.Join([][]byte{goCode, []byte(tsCode), []byte(javaCode)}, []byte("\n"))
+}
+
+func TestGeneratedCodeIsDeterministic(t *testing.T) {
+	opts := []Option{
+		WithSharedTypes(1), WithEnums(4), WithUnions(true), WithSumTypes(true), WithDynamicKeys(10), WithFieldOrder(FieldOrderSource),
+	}
+	want := generateAll(t, opts...)
+	for i := 0; i < 20; i++ {
+		if got := generateAll(t, opts...); !bytes.Equal(got, want) {
+			t.Fatalf("run %d: expected\n%s\ngot\n%s", i, want, got)
+		}
+	}
+}
+
+// goFieldNames returns the names of the fields of the Go type name in src.
+func goFieldNames(t *testing.T, src, name string) []string {
+	t.Helper()
+	start := strings.Index(src, "type "+name+" struct {\n")
+	if start < 0 {
+		t.Fatalf("no type %s in\n%s", name, src)
+	}
+	body := src[start+len("type "+name+" struct {\n"):]
+	body = body[:strings.Index(body, "\n}")]
+	var names []string
+	for _, m := range regexp.MustCompile(`(?m)^\t(\w+) `).FindAllStringSubmatch(body, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+func TestFieldOrder(t *testing.T) {
+	data := `{"items":[{"zeta":1,"alpha":"a","mid":null,"beta":1},{"zeta":2,"mid":true,"beta":2}]}`
+	tests := []struct {
+		order FieldOrder
+		want  string
+	}{
+		{FieldOrderAlphabetical, "Alpha Beta Mid Zeta"},
+		{FieldOrderSource, "Zeta Alpha Mid Beta"},
+		{FieldOrderRequiredFirst, "Beta Zeta Alpha Mid"},
+	}
+	for _, test := range tests {
+		src := generateGoFile(t, data, WithNamedTypes(true), WithFieldOrder(test.order))
+		if got := strings.Join(goFieldNames(t, src, "Item"), " "); got != test.want {
+			t.Errorf("order %d: expected %s, got %s", test.order, test.want, got)
+		}
+	}
+}
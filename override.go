@@ -0,0 +1,261 @@
+package oojson
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/structtag"
+	"golang.org/x/exp/maps"
+)
+
+// An Override changes the field generated for the properties that a selector
+// selects.
+type Override struct {
+	GoType   string            // Go type of the field, e.g. "decimal.Decimal"
+	GoImport string            // import path of GoType, e.g. "github.com/shopspring/decimal"
+	TsType   string            // TypeScript type of the property
+	JavaType string            // Java type of the field, imported if it is qualified, e.g. "java.math.BigDecimal"
+	Rename   string            // name of the field
+	Skip     bool              // omit the field
+	Optional bool              // generate the field as if the property was sometimes absent
+	Required bool              // generate the field as if the property was never absent or null
+	Tags     map[string]string // Go struct tags by key, e.g. "db": "price", replacing generated tags with the same key
+}
+
+// An overrideSelector is an Override with its parsed selector.
+type overrideSelector struct {
+	segments []string
+	override Override
+}
+
+// WithOverride overrides the field generated for the properties that selector
+// selects. Selectors are JSONPath-like: $ is the root, .name and ["name"]
+// select a property, .* any property and [] or [*] the elements of an array,
+// e.g. $.items[].price. Later overrides of a property replace earlier ones,
+// and objects that share a type share the overrides of their properties.
+func WithOverride(selector string, override Override) Option {
+	return func(target optionTarget) error {
+		segments, err := parseSelector(selector)
+		if err != nil {
+			return err
+		}
+		_, isGo := target.(*GoOption)
+		switch {
+		case override.Optional && override.Required:
+			return fmt.Errorf("oojson: override of %s is both optional and required", selector)
+		case override.Rename != "" && !token.IsIdentifier(override.Rename):
+			return fmt.Errorf("oojson: override of %s renames to %q, which is not a valid identifier", selector, override.Rename)
+		case override.Rename != "" && isGo && !token.IsExported(override.Rename):
+			return fmt.Errorf("oojson: override of %s renames to %q, which is not exported", selector, override.Rename)
+		case override.GoType != "" && !isGoTypeExpr(override.GoType):
+			return fmt.Errorf("oojson: override of %s has Go type %q, which is not a valid type", selector, override.GoType)
+		}
+		for key := range override.Tags {
+			if key == "" || strings.ContainsAny(key, " \t:\"`") {
+				return fmt.Errorf("oojson: override of %s has invalid tag key %q", selector, key)
+			}
+		}
+		c := target.common()
+		c.overrides = append(c.overrides, overrideSelector{segments: segments, override: override})
+		return nil
+	}
+}
+
+// isGoTypeExpr returns true if s is a Go type expression, e.g. "int",
+// "*decimal.Decimal" or "map[string][]byte".
+func isGoTypeExpr(s string) bool {
+	expr, err := parser.ParseExpr(s)
+	if err != nil {
+		return false
+	}
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident, *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.FuncType,
+			*ast.StructType, *ast.InterfaceType, *ast.IndexExpr, *ast.IndexListExpr:
+			return true
+		case *ast.SelectorExpr:
+			_, ok := e.X.(*ast.Ident)
+			return ok
+		default:
+			return false
+		}
+	}
+}
+
+// parseSelector returns the segments of selector: property names, "*" for any
+// property and "[]" for array elements.
+func parseSelector(selector string) ([]string, error) {
+	if !strings.HasPrefix(selector, "$") {
+		return nil, fmt.Errorf("oojson: selector %q does not start with $", selector)
+	}
+	var segments []string
+	rest := selector[1:]
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "[]"):
+			segments, rest = append(segments, "[]"), rest[2:]
+		case strings.HasPrefix(rest, "[*]"):
+			segments, rest = append(segments, "[]"), rest[3:]
+		case strings.HasPrefix(rest, `["`):
+			quoted, err := strconv.QuotedPrefix(rest[1:])
+			if err != nil || !strings.HasPrefix(rest[1+len(quoted):], "]") {
+				return nil, fmt.Errorf("oojson: selector %q has an unterminated property name", selector)
+			}
+			name, _ := strconv.Unquote(quoted)
+			segments, rest = append(segments, name), rest[len(quoted)+2:]
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("oojson: selector %q has an empty property name", selector)
+			}
+			segments, rest = append(segments, rest[1:end+1]), rest[end+1:]
+		default:
+			return nil, fmt.Errorf("oojson: selector %q has an unexpected %q", selector, rest)
+		}
+	}
+	if len(segments) == 0 || segments[len(segments)-1] == "[]" {
+		return nil, fmt.Errorf("oojson: selector %q does not select a property", selector)
+	}
+	return segments, nil
+}
+
+// matches returns true if the selector selects the property at path.
+func (s overrideSelector) matches(path []string) bool {
+	if len(path) != len(s.segments) {
+		return false
+	}
+	for i, segment := range s.segments {
+		if segment != path[i] && (segment != "*" || path[i] == "[]") {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveOverrides finds the properties of the root value v that the
+// overrides select. It must be called after unifyShapes, so that the
+// properties of shared types are overridden too.
+func (c *commonOption) resolveOverrides(v *Value) {
+	c.overridden = nil
+	if len(c.overrides) == 0 {
+		return
+	}
+	c.overridden = make(map[*Value]map[string]Override)
+	c.walkOverrides(v, nil)
+}
+
+// walkOverrides resolves the overrides of v, which is at path, and its
+// descendants. The values of dynamic keys are at path "*".
+func (c *commonOption) walkOverrides(v *Value, path []string) {
+	if v == nil {
+		return
+	}
+	path = path[:len(path):len(path)]
+	c.walkOverrides(v.ArrayElements, append(path, "[]"))
+	c.walkOverrides(v.AllObjectProperties, append(path, "*"))
+	for property, value := range v.ObjectProperties {
+		propertyPath := append(path, property)
+		for _, s := range c.overrides {
+			if s.matches(propertyPath) {
+				c.setOverride(v, property, s.override)
+			}
+		}
+		c.walkOverrides(value, propertyPath)
+	}
+	for _, variants := range v.Variants {
+		for _, variant := range variants {
+			c.walkOverrides(variant, path)
+		}
+	}
+}
+
+// setOverride overrides property of the object v and of its shape.
+func (c *commonOption) setOverride(v *Value, property string, override Override) {
+	shape, _ := c.shapes.shapeOf(v)
+	for _, object := range []*Value{v, shape} {
+		if c.overridden[object] == nil {
+			c.overridden[object] = make(map[string]Override)
+		}
+		c.overridden[object][property] = override
+	}
+}
+
+// override returns the override of property of the object v, which is the
+// zero Override if there is none.
+func (c *commonOption) override(v *Value, property string) Override {
+	return c.overridden[v][property]
+}
+
+// presence returns v with the absent and null counts that the override
+// requires.
+func (o Override) presence(v *Value) *Value {
+	if !o.Optional && !o.Required {
+		return v
+	}
+	p := *v
+	if o.Required {
+		p.Absents, p.Nulls = 0, 0
+	}
+	if o.Optional && p.Absents == 0 {
+		p.Absents = 1
+	}
+	return &p
+}
+
+// goTags returns the struct tags of the override sorted by key.
+func (o Override) goTags() []*structtag.Tag {
+	keys := maps.Keys(o.Tags)
+	sort.Strings(keys)
+	tags := make([]*structtag.Tag, 0, len(keys))
+	for _, key := range keys {
+		options := strings.Split(o.Tags[key], ",")
+		tags = append(tags, &structtag.Tag{Key: key, Name: options[0], Options: options[1:]})
+	}
+	return tags
+}
+
+// validatorTags returns the struct tags of the override in the form of
+// GetGoValidator.
+func (o Override) validatorTags() map[string]*StructTag {
+	tags := make(map[string]*StructTag, len(o.Tags))
+	for key, value := range o.Tags {
+		tag := newStructTag(key, ",", "=")
+		for _, option := range strings.Split(value, ",") {
+			name, value, _ := strings.Cut(option, "=")
+			tag.Set(name, value)
+		}
+		tags[key] = tag
+	}
+	return tags
+}
+
+// goTypeName returns the Go type of the override and adds its import.
+func (o Override) goTypeName(options *GoOption) string {
+	if o.GoImport != "" {
+		options.Imports[o.GoImport] = struct{}{}
+	}
+	return o.GoType
+}
+
+// javaTypeName returns the Java type of the override and adds its import if it
+// is qualified.
+func (o Override) javaTypeName(options *JavaOption) string {
+	i := strings.LastIndexByte(o.JavaType, '.')
+	if i < 0 {
+		return o.JavaType
+	}
+	options.imports[o.JavaType] = struct{}{}
+	return o.JavaType[i+1:]
+}
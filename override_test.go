@@ -0,0 +1,27 @@
+package oojson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOverrideRejectsInvalidGoType(t *testing.T) {
+	for _, goType := range []string{"not a type", "1 + 2", "a.b.c"} {
+		if _, err := NewGoOption(WithOverride("$.price", Override{GoType: goType})); err == nil {
+			t.Errorf("expected an error for Go type %q", goType)
+		}
+	}
+	if _, err := NewGoOption(WithOverride("$.price", Override{GoType: "*decimal.Decimal"})); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOverrideRenameCollision(t *testing.T) {
+	src := generateGoFile(t, `{"id":1,"user_id":2}`, WithOverride("$.user_id", Override{Rename: "ID"}))
+	fields := strings.Join(strings.Fields(src), " ")
+	for _, field := range []string{"ID int `json:\"user_id\"`", "ID2 int `json:\"id\"`"} {
+		if !strings.Contains(fields, field) {
+			t.Errorf("expected field %s in\n%s", field, src)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package oojson
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"golang.org/x/exp/maps"
+)
+
+// Shapes groups the objects of a Value tree that have the same or similar
+// shapes so that generators emit a single shared type for each group.
+type Shapes struct {
+	threshold     float64
+	recursiveOnly bool // only unify objects with their descendants
+	groups        []*shapeGroup
+	byValue       map[*Value]*shapeGroup
+	root          *shapeGroup         // group of the root value, if any
+	union         func(*Value) *union // union of an object, whose variants are walked instead of its properties
+}
+
+// A shapeGroup is a set of objects that share a type.
+type shapeGroup struct {
+	value   *Value   // merged observations of all members
+	members int      // number of objects in the tree with this shape
+	names   []string // type names suggested by the members' properties
+}
+
+// UnifyShapes fingerprints the objects in v and groups the compatible ones.
+// Objects are compatible if the Jaccard similarity of their property names is
+// at least threshold and their common properties have compatible types. A
+// threshold of 1 only groups identical shapes. Properties that only some
+// members have become optional in the shared type.
+func UnifyShapes(v *Value, threshold float64) *Shapes {
+	return unifyShapes(v, &Shapes{threshold: threshold})
+}
+
+// UnifyRecursiveShapes is like UnifyShapes but only unifies objects with
+// compatible descendants, such as the replies of a comment thread. Their type
+// refers to itself instead of nesting as deep as the observed data.
+func UnifyRecursiveShapes(v *Value, threshold float64) *Shapes {
+	return unifyShapes(v, &Shapes{threshold: threshold, recursiveOnly: true})
+}
+
+// unifyShapes groups the objects in v with the settings of s, and returns s.
+func unifyShapes(v *Value, s *Shapes) *Shapes {
+	s.byValue = make(map[*Value]*shapeGroup)
+	s.walk(v, "")
+	s.root = s.byValue[v]
+	return s
+}
+
+// walk groups the objects in v, children first, and returns the groups of
+// the objects in v and its descendants. The descendants of unions are those of
+// their variants. name is the type name suggested by the
+// property v was found at.
+func (s *Shapes) walk(v *Value, name string) map[*shapeGroup]bool {
+	groups := make(map[*shapeGroup]bool)
+	if v == nil {
+		return groups
+	}
+	if v.ArrayElements != nil {
+		maps.Copy(groups, s.walk(v.ArrayElements, singularize(name)))
+	}
+	objects := []*Value{v}
+	if s.union != nil {
+		if u := s.union(v); u != nil {
+			objects = objects[:0]
+			for _, value := range u.values {
+				objects = append(objects, u.variants[value])
+			}
+		}
+	}
+	for _, object := range objects {
+		properties := maps.Keys(object.ObjectProperties)
+		sort.Strings(properties)
+		for _, property := range properties {
+			maps.Copy(groups, s.walk(object.ObjectProperties[property], strcase.ToCamel(property)))
+		}
+	}
+	if !isShapedObject(v) {
+		return groups
+	}
+
+	for _, g := range s.groups {
+		if s.recursiveOnly && !groups[g] {
+			continue
+		}
+		if s.compatibleShapes(g.value, v) {
+			g.value.merge(v, nil)
+			s.link(g.value, v)
+			g.members++
+			g.names = append(g.names, name)
+			s.byValue[v] = g
+			groups[g] = true
+			return groups
+		}
+	}
+	g := &shapeGroup{value: &Value{}, members: 1, names: []string{name}}
+	g.value.merge(v, nil)
+	s.link(g.value, v)
+	s.groups = append(s.groups, g)
+	s.byValue[v] = g
+	s.byValue[g.value] = g
+	groups[g] = true
+	return groups
+}
+
+// link maps the objects in merged, a copy of or merge into, to the groups of
+// the corresponding objects in v.
+func (s *Shapes) link(merged, v *Value) {
+	if merged == nil || v == nil {
+		return
+	}
+	if g, ok := s.byValue[v]; ok {
+		if _, ok := s.byValue[merged]; !ok {
+			s.byValue[merged] = g
+		}
+	}
+	s.link(merged.ArrayElements, v.ArrayElements)
+	for property, value := range v.ObjectProperties {
+		s.link(merged.ObjectProperties[property], value)
+	}
+}
+
+// compatibleShapes returns true if the objects a and b can share a type.
+func (s *Shapes) compatibleShapes(a, b *Value) bool {
+	common := 0
+	for property, value := range a.ObjectProperties {
+		other, ok := b.ObjectProperties[property]
+		if !ok {
+			continue
+		}
+		if !s.compatible(value, other) {
+			return false
+		}
+		common++
+	}
+	union := len(a.ObjectProperties) + len(b.ObjectProperties) - common
+	return float64(common)/float64(union) >= s.threshold
+}
+
+// compatible returns true if a and b can be generated as the same type.
+// Values that were only null, and empty arrays and objects, are compatible with
+// anything of their kind.
+func (s *Shapes) compatible(a, b *Value) bool {
+	if a == nil || b == nil {
+		return true
+	}
+	aKinds, bKinds := valueKinds(a), valueKinds(b)
+	if aKinds != 0 && bKinds != 0 && aKinds != bKinds {
+		return false
+	}
+	if !s.compatible(a.ArrayElements, b.ArrayElements) {
+		return false
+	}
+	if isShapedObject(a) && isShapedObject(b) {
+		if g, ok := s.byValue[a]; ok && g == s.byValue[b] {
+			return true
+		}
+		return s.compatibleShapes(a, b)
+	}
+	return true
+}
+
+// shapeOf returns the Value that the type of v is generated from and whether
+// that type is shared with other objects.
+func (s *Shapes) shapeOf(v *Value) (*Value, bool) {
+	if s == nil {
+		return v, false
+	}
+	g, ok := s.byValue[v]
+	if !ok || g.members < 2 {
+		return v, false
+	}
+	return g.value, true
+}
+
+// name returns the name of the shared type shape. It is the longest common
+// suffix of the names suggested by the members, or name if there is none or
+// the root value has the shape.
+func (s *Shapes) name(shape *Value, name string) string {
+	g := s.byValue[shape]
+	if g == s.root && name != "" {
+		return name
+	}
+	var suffix []string
+	first := true
+	for _, memberName := range g.names {
+		if memberName == "" {
+			continue
+		}
+		components := SplitComponents(memberName)
+		if first {
+			suffix = components
+			first = false
+			continue
+		}
+		n := 0
+		for n < len(suffix) && n < len(components) &&
+			suffix[len(suffix)-1-n] == components[len(components)-1-n] {
+			n++
+		}
+		suffix = suffix[len(suffix)-n:]
+	}
+	if len(suffix) == 0 {
+		return name
+	}
+	return strings.Join(suffix, "")
+}
+
+// isShapedObject returns true if v is an object with properties, whose type is
+// a struct or class.
+func isShapedObject(v *Value) bool {
+	return v.Objects > 0 && len(v.ObjectProperties) > 0
+}
+
+// Kinds of observed values, ignoring nulls.
+const (
+	kindArray = 1 << iota
+	kindBool
+	kindNumber
+	kindObject
+	kindString
+)
+
+// valueKinds returns the kinds of values observed in v.
+func valueKinds(v *Value) int {
+	kinds := 0
+	if v.Arrays > 0 {
+		kinds |= kindArray
+	}
+	if v.Bools > 0 {
+		kinds |= kindBool
+	}
+	if v.Float64s > 0 || v.Ints > 0 {
+		kinds |= kindNumber
+	}
+	if v.Objects > 0 {
+		kinds |= kindObject
+	}
+	if v.Strings > 0 {
+		kinds |= kindString
+	}
+	return kinds
+}
@@ -0,0 +1,66 @@
+package oojson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecursiveFieldsArePointers(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		opt   Option
+		field string
+	}{
+		{
+			name:  "recursive",
+			data:  `{"text":"a","parent":{"text":"b"}}`,
+			opt:   WithRecursiveTypes(0.5),
+			field: "Parent *Root",
+		},
+		{
+			name:  "shared",
+			data:  `[{"status":"a","x":{"status":"a"}}]`,
+			opt:   WithSharedTypes(0.5),
+			field: "X *Item",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := generateGoFile(t, test.data, test.opt)
+			if !strings.Contains(strings.Join(strings.Fields(src), " "), test.field) {
+				t.Errorf("expected field %q in\n%s", test.field, src)
+			}
+		})
+	}
+}
+
+func TestSharedTypes(t *testing.T) {
+	data := `{"billing_address":{"city":"a","zip":"1"},"shipping_address":{"city":"b","zip":"2"}}`
+	src := generateGoFile(t, data, WithSharedTypes(1), WithNamedTypes(true))
+	if strings.Count(src, "struct {") != 2 || !strings.Contains(src, "BillingAddress  Address `json:\"billing_address\"`") ||
+		!strings.Contains(src, "ShippingAddress Address `json:\"shipping_address\"`") {
+		t.Errorf("expected one Address type for both addresses, got\n%s", src)
+	}
+
+	v := observeJSON(t, data)
+	_, tsCode := GetTsType(v, "Root", "  ", DefaultTsOption(WithSharedTypes(1)))
+	if strings.Count(tsCode, "type Address = {") != 1 || !strings.Contains(tsCode, "shippingAddress: Address;") {
+		t.Errorf("expected one Address type in TypeScript, got\n%s", tsCode)
+	}
+	_, javaCode := GetJavaType(v, "Root", "  ", DefaultJavaOption(WithSharedTypes(1)))
+	if strings.Count(javaCode, "class Address {") != 1 || !strings.Contains(javaCode, "private Address shippingAddress;") {
+		t.Errorf("expected one Address class in Java, got\n%s", javaCode)
+	}
+}
+
+func TestSimilarShapes(t *testing.T) {
+	data := `{"home":{"city":"a","zip":"1"},"work":{"city":"b","zip":"2","phone":"x"}}`
+	src := generateGoFile(t, data, WithSharedTypes(0.6), WithNamedTypes(true))
+	if strings.Count(src, "struct {") != 2 || !strings.Contains(src, "Phone string `json:\"phone,omitempty\"`") {
+		t.Errorf("expected one type with an optional phone, got\n%s", src)
+	}
+	if src := generateGoFile(t, data, WithSharedTypes(1), WithNamedTypes(true)); strings.Count(src, "struct {") != 3 {
+		t.Errorf("expected separate types with threshold 1, got\n%s", src)
+	}
+}
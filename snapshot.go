@@ -0,0 +1,69 @@
+package oojson
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// SnapshotVersion is the version of the snapshots written by Save. It changes
+// when the meaning of a field of Value changes. Fields that are added are zero
+// in older snapshots, which Load handles like Values observed before the field
+// existed.
+const SnapshotVersion = 1
+
+// A snapshot is the serialized form of a Value.
+type snapshot struct {
+	Version int    `json:"version"`
+	Value   *Value `json:"value"`
+}
+
+// Save writes v to w as a versioned JSON snapshot that Load reads. Snapshots
+// are stable: the same Value is always written the same way, so they can be
+// checked in and diffed.
+func (v *Value) Save(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(snapshot{Version: SnapshotVersion, Value: v})
+}
+
+// SaveCompressed writes v to w as a gzip compressed snapshot, which is much
+// smaller for large trees and which Load reads as well.
+func (v *Value) SaveCompressed(w io.Writer) error {
+	gw := gzip.NewWriter(w)
+	if err := v.Save(gw); err != nil {
+		gw.Close()
+		return err
+	}
+	return gw.Close()
+}
+
+// Load reads a snapshot written by Save from r, which may be gzip compressed.
+// Snapshots of a newer version than SnapshotVersion are rejected.
+func Load(r io.Reader) (*Value, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		r = gr
+	} else {
+		r = br
+	}
+
+	var s snapshot
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("oojson: invalid snapshot: %w", err)
+	}
+	switch {
+	case s.Version == 0 || s.Value == nil:
+		return nil, fmt.Errorf("oojson: invalid snapshot: missing version or value")
+	case s.Version > SnapshotVersion:
+		return nil, fmt.Errorf("oojson: snapshot version %d is newer than %d", s.Version, SnapshotVersion)
+	}
+	return s.Value, nil
+}
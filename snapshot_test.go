@@ -0,0 +1,84 @@
+package oojson
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	data := `{"id":"12","name":"a","tags":["x","y"],"items":[{"n":1.5,"at":"2024-01-02T03:04:05Z"},{"n":null}]}`
+	v := observeJSON(t, data)
+	for _, save := range []func(*Value, *bytes.Buffer) error{
+		func(v *Value, b *bytes.Buffer) error { return v.Save(b) },
+		func(v *Value, b *bytes.Buffer) error { return v.SaveCompressed(b) },
+	} {
+		b := &bytes.Buffer{}
+		if err := save(v, b); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := Load(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(loaded, v) {
+			t.Errorf("expected the loaded tree to equal the saved one")
+		}
+		options := []Option{WithStringifiedTypes(true), WithAutoIntType(true)}
+		want, err := GenerateGoFile(v, GoFileOptions{Package: "model", Options: DefaultGoOption(options...)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := GenerateGoFile(loaded, GoFileOptions{Package: "model", Options: DefaultGoOption(options...)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("expected\n%s\ngot\n%s", want, got)
+		}
+	}
+}
+
+// oldSnapshot was written before Value had MinStringInt, MaxStringInt and
+// IntValues.
+const oldSnapshot = `{
+  "version": 1,
+  "value": {
+    "Observations": 4,
+    "Objects": 4,
+    "ObjectProperties": {
+      "id": {"Observations": 4, "Strings": 4, "StringInts": 4, "StringFloats": 4},
+      "n": {"Observations": 4, "Ints": 4, "MinNumber": 1, "MaxNumber": 2}
+    }
+  }
+}`
+
+func TestLoadOldSnapshot(t *testing.T) {
+	v, err := Load(strings.NewReader(oldSnapshot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := GenerateGoFile(v, GoFileOptions{
+		Package: "model",
+		Options: DefaultGoOption(WithStringifiedTypes(true), WithAutoIntType(true), WithEnums(8)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The range of the stringified integers is unknown, so they get the widest
+	// type, and integers without tracked values are not an enum.
+	for _, field := range []string{"ID int64 `json:\"id,string\"`", "N  int8"} {
+		if !strings.Contains(string(src), field) {
+			t.Errorf("expected %s, got\n%s", field, src)
+		}
+	}
+
+	v, err = NewObserver().ObserveJSON(v, []byte(`{"id":"7","n":3}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := v.ObjectProperties["n"]; n.Ints != 5 || n.MaxNumber != 3 {
+		t.Errorf("expected observations to extend the loaded tree, got %+v", n)
+	}
+}
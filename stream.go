@@ -0,0 +1,83 @@
+package oojson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// A StreamMode is the layout of the JSON values read by ObserveReader.
+type StreamMode int
+
+// Stream modes.
+const (
+	StreamValues StreamMode = iota // NDJSON or concatenated JSON values, each of which is a sample
+	StreamArray                    // a top-level array, each of whose elements is a sample
+)
+
+// ProgressInterval is the number of values between calls of
+// Observer.Progress.
+const ProgressInterval = 1000
+
+// A Progress reports how far ObserveReader has read.
+type Progress struct {
+	Values int   // values observed so far
+	Bytes  int64 // bytes of the input read so far
+	Done   bool  // whether all values have been read
+}
+
+// ObserveReader observes the samples in r with the default settings.
+func ObserveReader(r io.Reader, mode StreamMode) (*Value, error) {
+	return NewObserver().ObserveReader(nil, r, mode)
+}
+
+// ObserveReader merges the samples in r into v. Samples are decoded token by
+// token and observed one at a time, so memory does not grow with the size of
+// r. In StreamArray mode v describes an element of the array, like the
+// ArrayElements of the Value of the whole array. Numbers are decoded as by
+// ObserveJSON.
+func (o *Observer) ObserveReader(v *Value, r io.Reader, mode StreamMode) (*Value, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	switch mode {
+	case StreamValues:
+	case StreamArray:
+		token, err := decoder.Token()
+		if err != nil {
+			return v, err
+		}
+		if token != json.Delim('[') {
+			return v, fmt.Errorf("oojson: expected a top-level array, got %v", token)
+		}
+	default:
+		return v, fmt.Errorf("oojson: invalid stream mode %d", mode)
+	}
+
+	values := 0
+	for mode == StreamValues || decoder.More() {
+		a, err := decodeValue(decoder)
+		if err == io.EOF && mode == StreamValues {
+			break
+		}
+		if err != nil {
+			return v, fmt.Errorf("oojson: value %d at offset %d: %w", values, decoder.InputOffset(), err)
+		}
+		v = o.Observe(v, a)
+		values++
+		if o.Progress != nil && values%ProgressInterval == 0 {
+			o.Progress(Progress{Values: values, Bytes: decoder.InputOffset()})
+		}
+	}
+	if mode == StreamArray {
+		if _, err := decoder.Token(); err != nil {
+			return v, fmt.Errorf("oojson: unterminated top-level array: %w", nestedError(err))
+		}
+		if _, err := decoder.Token(); err != io.EOF {
+			return v, fmt.Errorf("oojson: unexpected data after the top-level array")
+		}
+	}
+	if o.Progress != nil {
+		o.Progress(Progress{Values: values, Bytes: decoder.InputOffset(), Done: true})
+	}
+	return v, nil
+}
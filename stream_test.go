@@ -0,0 +1,77 @@
+package oojson
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestObserveReader(t *testing.T) {
+	whole := observeJSON(t, `[{"a":1},{"a":2,"b":"x"},{"a":null}]`)
+	values, err := NewObserver().ObserveJSON(nil, []byte(`{"a":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err = NewObserver().ObserveJSON(values, []byte(`{"a":2,"b":"x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err = NewObserver().ObserveJSON(values, []byte(`{"a":null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		mode  StreamMode
+		want  *Value
+		err   string
+	}{
+		{name: "ndjson", input: "{\"a\":1}\n{\"a\":2,\"b\":\"x\"}\n{\"a\":null}\n", mode: StreamValues, want: values},
+		{name: "concatenated", input: `{"a":1}{"a":2,"b":"x"}{"a":null}`, mode: StreamValues, want: values},
+		{name: "array", input: `[{"a":1},{"a":2,"b":"x"},{"a":null}]`, mode: StreamArray, want: whole.ArrayElements},
+		{name: "truncated array", input: `[{"a":1},{"a":2}`, mode: StreamArray, err: "oojson: value 2 at offset 16: unexpected end of JSON input"},
+		{name: "truncated element", input: `[{"a":1},{"a"`, mode: StreamArray, err: "oojson: value 1 at offset 13: unexpected EOF"},
+		{name: "mismatched bracket", input: `[{"a":1}}`, mode: StreamArray, err: "oojson: unterminated top-level array"},
+		{name: "trailing data", input: `[{"a":1}] {"a":2}`, mode: StreamArray, err: "oojson: unexpected data after the top-level array"},
+		{name: "not an array", input: `{"a":1}`, mode: StreamArray, err: "oojson: expected a top-level array, got {"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := ObserveReader(strings.NewReader(test.input), test.mode)
+			if test.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+					t.Errorf("expected an error starting with %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(v, test.want) {
+				t.Errorf("expected %+v, got %+v", test.want, v)
+			}
+		})
+	}
+}
+
+func TestObserveReaderProgress(t *testing.T) {
+	input := strings.Repeat("{\"a\":1}\n", 2*ProgressInterval+1)
+	var progress []Progress
+	observer := NewObserver()
+	observer.Progress = func(p Progress) {
+		progress = append(progress, p)
+	}
+	if _, err := observer.ObserveReader(nil, strings.NewReader(input), StreamValues); err != nil {
+		t.Fatal(err)
+	}
+	want := []Progress{
+		{Values: ProgressInterval, Bytes: int64(len("{\"a\":1}\n")*ProgressInterval - 1)},
+		{Values: 2 * ProgressInterval, Bytes: int64(len("{\"a\":1}\n")*2*ProgressInterval - 1)},
+		{Values: 2*ProgressInterval + 1, Bytes: int64(len(input) - 1), Done: true},
+	}
+	if !reflect.DeepEqual(progress, want) {
+		t.Errorf("expected %+v, got %+v", want, progress)
+	}
+}
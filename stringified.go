@@ -0,0 +1,157 @@
+package oojson
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// A stringKind is the kind of value that all strings of a Value encode.
+type stringKind int
+
+// String kinds.
+const (
+	stringPlain stringKind = iota // strings that do not all encode one kind
+	stringInt                     // strings that encode integers, e.g. "12345"
+	stringFloat                   // strings that encode numbers, e.g. "9.99"
+	stringBool                    // strings that encode booleans, e.g. "true"
+)
+
+// jsonNumberRegexp matches JSON numbers, which unlike strconv.ParseFloat
+// rejects leading zeros, hexadecimal and special values.
+var jsonNumberRegexp = regexp.MustCompile(`^-?(?:0|[1-9]\d*)(\.\d+)?([eE][+-]?\d+)?$`)
+
+// WithStringifiedTypes sets whether strings that all encode integers, numbers
+// or booleans, such as "12345", "9.99" and "true", get the type that they
+// encode. Go fields get the ",string" option of encoding/json and Java fields
+// get @JsonFormat(shape = JsonFormat.Shape.STRING).
+func WithStringifiedTypes(enabled bool) Option {
+	return func(target optionTarget) error {
+		target.common().stringifiedTypes = enabled
+		return nil
+	}
+}
+
+// stringKind returns the kind of value that the strings of v encode if
+// stringified types are enabled and v is only strings and nulls.
+func (c *commonOption) stringKind(v *Value) stringKind {
+	if !c.stringifiedTypes || v.Strings == 0 || v.Strings+v.Nulls != v.Observations {
+		return stringPlain
+	}
+	switch {
+	case v.StringInts == v.Strings:
+		return stringInt
+	case v.StringFloats == v.Strings:
+		return stringFloat
+	case v.StringBools == v.Strings:
+		return stringBool
+	default:
+		return stringPlain
+	}
+}
+
+// observeStringified counts the string s of v if it encodes a number or a
+// boolean.
+func observeStringified(v *Value, s string) {
+	switch {
+	case s == "true" || s == "false":
+		v.StringBools++
+		if s == "false" {
+			v.StringZeros++
+		}
+	case jsonNumberRegexp.MatchString(s):
+		v.StringFloats++
+		m := jsonNumberRegexp.FindStringSubmatch(s)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil && m[1] == "" && m[2] == "" {
+			if v.StringInts == 0 || i < v.MinStringInt {
+				v.MinStringInt = i
+			}
+			if v.StringInts == 0 || i > v.MaxStringInt {
+				v.MaxStringInt = i
+			}
+			v.StringInts++
+		}
+		if f, _ := strconv.ParseFloat(s, 64); f == 0 {
+			v.StringZeros++
+		}
+	}
+}
+
+// goStringifiedType returns the Go type of the property v if its strings are
+// unmarshalled with the ",string" option, or "" otherwise. Times take
+// precedence, and the type is a pointer if v was null.
+func (o *GoOption) goStringifiedType(v *Value) string {
+	if o.timestamp(v) != nil {
+		return ""
+	}
+	var goType string
+	switch o.stringKind(v) {
+	case stringInt:
+		goType = o.goIntType(stringInts(v))
+	case stringFloat:
+		goType = "float64"
+	case stringBool:
+		goType = "bool"
+	default:
+		return ""
+	}
+	if v.Nulls > 0 {
+		goType = "*" + goType
+	}
+	return goType
+}
+
+// stringInts returns a Value with the range of the integers that the strings
+// of v encode, so that their type is chosen like that of integers.
+func stringInts(v *Value) *Value {
+	ints := &Value{Ints: v.StringInts, MinNumber: float64(v.MinStringInt), MaxNumber: float64(v.MaxStringInt)}
+	if v.MinStringInt == 0 && v.MaxStringInt == 0 && v.StringZeros < v.StringInts {
+		// The range is unknown in snapshots saved before it was tracked.
+		ints.MinNumber, ints.MaxNumber = math.MinInt64, math.MaxInt64
+	}
+	return ints
+}
+
+// stringifiedOmitEmpty returns true if the stringified property v was absent
+// but never null or zero, so that omitempty does not drop observed values.
+func stringifiedOmitEmpty(v *Value, observations int) bool {
+	return v.Strings < observations && v.Nulls == 0 && v.StringZeros == 0
+}
+
+// getGoStringifiedValidator returns the Go type and struct tags of the
+// property v if its strings are unmarshalled with the ",string" option, or ""
+// otherwise.
+func getGoStringifiedValidator(v *Value, observations int, options *GoOption) (string, map[string]*StructTag) {
+	goType := options.goStringifiedType(v)
+	if goType == "" {
+		return "", nil
+	}
+	validatorTag := newStructTag("validate", ",", "=")
+	jsonTag := newStructTag("json", ",", "=")
+	if v.Nulls == 0 {
+		validatorTag.Set("required", "")
+	}
+	if stringifiedOmitEmpty(v, observations) {
+		jsonTag.Set(JSON_OMITEMPTY, "")
+	}
+	jsonTag.Set("string", "")
+	return goType, map[string]*StructTag{jsonTag.Key: jsonTag, validatorTag.Key: validatorTag}
+}
+
+// getJavaStringifiedType returns the Java type of the property v if its
+// strings are coerced by Jackson, or "" otherwise. Times take precedence.
+func getJavaStringifiedType(v *Value, options *JavaOption) string {
+	if options.timestamp(v) != nil {
+		return ""
+	}
+	switch options.stringKind(v) {
+	case stringInt:
+		return JavaLong
+	case stringFloat:
+		return JavaFloat
+	case stringBool:
+		return JavaBool
+	default:
+		return ""
+	}
+}
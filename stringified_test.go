@@ -0,0 +1,22 @@
+package oojson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStringifiedIntTypes(t *testing.T) {
+	data := `{"items":[{"id":"12"},{"id":"-100"}]}`
+	src := generateGoFile(t, data, WithStringifiedTypes(true), WithAutoIntType(true))
+	if !strings.Contains(src, "int8") || !strings.Contains(src, `json:"id,string"`) {
+		t.Errorf("expected an int8 field with the string option, got\n%s", src)
+	}
+
+	v := observeJSON(t, `{"id":"12"}`)
+	other := observeJSON(t, `{"id":"40000"}`)
+	v = NewObserver().Merge(v, other)
+	options := DefaultGoOption(WithStringifiedTypes(true), WithAutoIntType(true))
+	if goType := options.goStringifiedType(v.ObjectProperties["id"]); goType != "int32" {
+		t.Errorf("expected int32 after merging, got %s", goType)
+	}
+}
@@ -0,0 +1,252 @@
+package oojson
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A sumKind is a primitive kind of the values of a sum type.
+type sumKind int
+
+// Sum kinds, in the order that sum types try them.
+const (
+	sumString sumKind = iota
+	sumInt
+	sumNumber
+	sumBool
+)
+
+// sumKindNames are the names of the sum kinds in the names of sum types.
+var sumKindNames = map[sumKind]string{
+	sumString: "String",
+	sumInt:    "Int",
+	sumNumber: "Number",
+	sumBool:   "Bool",
+}
+
+// sumKindJSONNames are the names of the JSON values of the sum kinds.
+var sumKindJSONNames = map[sumKind]string{
+	sumString: "string",
+	sumInt:    "integer",
+	sumNumber: "number",
+	sumBool:   "boolean",
+}
+
+// WithSumTypes sets whether values that were strings, numbers and booleans in
+// different observations become sum types, such as StringOrNumber in Go and
+// string | number in TypeScript, instead of any.
+func WithSumTypes(enabled bool) Option {
+	return func(target optionTarget) error {
+		target.common().sumTypes = enabled
+		return nil
+	}
+}
+
+// sumType returns the kinds of the values of v if they are a mix of at least
+// two primitive kinds and nulls, or nil otherwise. Integers and floats are a
+// single number kind.
+func (c *commonOption) sumType(v *Value) []sumKind {
+	if !c.sumTypes || v.Arrays > 0 || v.Objects > 0 {
+		return nil
+	}
+	var kinds []sumKind
+	if v.Strings > 0 {
+		kinds = append(kinds, sumString)
+	}
+	switch {
+	case v.Float64s > 0:
+		kinds = append(kinds, sumNumber)
+	case v.Ints > 0:
+		kinds = append(kinds, sumInt)
+	}
+	if v.Bools > 0 {
+		kinds = append(kinds, sumBool)
+	}
+	if len(kinds) < 2 {
+		return nil
+	}
+	return kinds
+}
+
+// sumTypeName returns the name of the sum type of kinds, e.g. StringOrNumber.
+func sumTypeName(kinds []sumKind) string {
+	names := make([]string, len(kinds))
+	for i, kind := range kinds {
+		names[i] = sumKindNames[kind]
+	}
+	return strings.Join(names, "Or")
+}
+
+// sumTypeDescription returns the JSON values of kinds in a sentence, e.g.
+// "string, number or boolean".
+func sumTypeDescription(kinds []sumKind) string {
+	names := make([]string, len(kinds))
+	for i, kind := range kinds {
+		names[i] = sumKindJSONNames[kind]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+// declareGoSumType declares a struct with a pointer field for each of kinds
+// that is marshalled as the value of the field that is set, and returns its
+// name. Each sum type is declared once.
+func (o *GoOption) declareGoSumType(v *Value, kinds []sumKind) string {
+	goTypes := make([]string, len(kinds))
+	for i, kind := range kinds {
+		switch kind {
+		case sumString:
+			goTypes[i] = "string"
+		case sumInt:
+			goTypes[i] = o.goIntType(v)
+		case sumNumber:
+			goTypes[i] = "float64"
+		case sumBool:
+			goTypes[i] = "bool"
+		}
+	}
+	name := sumTypeName(kinds)
+	key := name + " " + strings.Join(goTypes, " ")
+	if typeName, ok := o.helperTypeNames[key]; ok {
+		return typeName
+	}
+	typeName := o.reserveGoTypeName(name)
+	o.helperTypeNames[key] = typeName
+
+	o.Imports["encoding/json"] = struct{}{}
+	o.Imports["fmt"] = struct{}{}
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "// %s is a JSON %s.\n", typeName, sumTypeDescription(kinds))
+	fmt.Fprintf(b, "// The field of the decoded kind is set, and no field is set for null.\n")
+	fmt.Fprintf(b, "type %s struct {\n", typeName)
+	for i, kind := range kinds {
+		fmt.Fprintf(b, "%s *%s\n", sumKindNames[kind], goTypes[i])
+	}
+	fmt.Fprintf(b, "}\n\n")
+
+	fmt.Fprintf(b, "// UnmarshalJSON decodes data into the field of its kind.\n")
+	fmt.Fprintf(b, "func (s *%s) UnmarshalJSON(data []byte) error {\n", typeName)
+	fmt.Fprintf(b, "*s = %s{}\n", typeName)
+	fmt.Fprintf(b, "if string(data) == \"null\" {\nreturn nil\n}\n")
+	for i, kind := range kinds {
+		fmt.Fprintf(b, "var value%d %s\n", i, goTypes[i])
+		fmt.Fprintf(b, "if err := json.Unmarshal(data, &value%d); err == nil {\n", i)
+		fmt.Fprintf(b, "s.%s = &value%d\nreturn nil\n}\n", sumKindNames[kind], i)
+	}
+	fmt.Fprintf(b, "return fmt.Errorf(\"%s: cannot unmarshal %%s\", data)\n}\n\n", typeName)
+
+	fmt.Fprintf(b, "// MarshalJSON encodes the field that is set, or null if none is.\n")
+	fmt.Fprintf(b, "func (s %s) MarshalJSON() ([]byte, error) {\n", typeName)
+	fmt.Fprintf(b, "switch {\n")
+	for _, kind := range kinds {
+		fmt.Fprintf(b, "case s.%[1]s != nil:\nreturn json.Marshal(*s.%[1]s)\n", sumKindNames[kind])
+	}
+	fmt.Fprintf(b, "}\nreturn []byte(\"null\"), nil\n}\n")
+
+	for i, kind := range kinds {
+		fmt.Fprintf(b, "\n// As%s returns the %s value of s, and whether s holds one.\n", sumKindNames[kind], sumKindJSONNames[kind])
+		fmt.Fprintf(b, "func (s %s) As%s() (value %s, ok bool) {\n", typeName, sumKindNames[kind], goTypes[i])
+		fmt.Fprintf(b, "if s.%[1]s != nil {\nvalue, ok = *s.%[1]s, true\n}\nreturn\n}\n", sumKindNames[kind])
+	}
+	o.declareGoSource(b.String())
+	return typeName
+}
+
+// getTsSumType returns the TypeScript union of the primitive types kinds.
+func getTsSumType(v *Value, kinds []sumKind) string {
+	tsTypes := make([]string, len(kinds))
+	for i, kind := range kinds {
+		switch kind {
+		case sumString:
+			tsTypes[i] = TsString
+		case sumInt:
+			tsTypes[i] = getTsIntType(v)
+		case sumNumber:
+			tsTypes[i] = TsNumber
+		case sumBool:
+			tsTypes[i] = TsBool
+		}
+	}
+	return strings.Join(tsTypes, " | ")
+}
+
+// getJavaSumType returns the name of a class that holds a value of one of
+// kinds, which Jackson deserializes with a custom deserializer and serializes
+// as the value. The class is named after the Java types of kinds, e.g.
+// StringOrLong, and shared by all properties with the same types.
+func getJavaSumType(v *Value, kinds []sumKind, indent string, options *JavaOption) string {
+	javaTypes := make([]string, len(kinds))
+	readers := make([]string, len(kinds))
+	memberNames := make([]string, len(kinds))
+	for i, kind := range kinds {
+		memberNames[i] = sumKindNames[kind]
+		switch kind {
+		case sumString:
+			javaTypes[i], readers[i] = JavaString, "p.getText()"
+		case sumInt:
+			javaTypes[i] = getJavaIntType(v, options)
+			switch javaTypes[i] {
+			case JavaBigInt:
+				readers[i], memberNames[i] = "p.getBigIntegerValue()", JavaBigInt
+			case JavaLong:
+				readers[i], memberNames[i] = "p.getLongValue()", JavaLong
+			default:
+				readers[i] = "p.getIntValue()"
+			}
+		case sumNumber:
+			javaTypes[i], readers[i] = JavaFloat, "p.getFloatValue()"
+		case sumBool:
+			javaTypes[i], readers[i] = JavaBool, "p.getBooleanValue()"
+		}
+	}
+	name := strings.Join(memberNames, "Or")
+	if _, ok := options.sharedClasses[name]; ok {
+		return name
+	}
+	options.imports["com.fasterxml.jackson.annotation.JsonValue"] = struct{}{}
+	options.imports["com.fasterxml.jackson.core.JsonParser"] = struct{}{}
+	options.imports["com.fasterxml.jackson.databind.DeserializationContext"] = struct{}{}
+	options.imports["com.fasterxml.jackson.databind.JsonDeserializer"] = struct{}{}
+	options.imports["com.fasterxml.jackson.databind.annotation.JsonDeserialize"] = struct{}{}
+	options.imports["java.io.IOException"] = struct{}{}
+
+	in2 := indent + indent
+	in3 := in2 + indent
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "@JsonDeserialize(using = %s.Deserializer.class)\n", name)
+	fmt.Fprintf(b, "class %s {\n", name)
+	fmt.Fprintf(b, "%vprivate final Object value;\n", indent)
+	for i := range kinds {
+		fmt.Fprintf(b, "%vpublic %s(%s value) {\n%vthis.value = value;\n%v}\n", indent, name, javaTypes[i], in2, indent)
+	}
+	fmt.Fprintf(b, "%v@JsonValue\n", indent)
+	fmt.Fprintf(b, "%vpublic Object getValue() {\n%vreturn value;\n%v}\n", indent, in2, indent)
+	for i := range kinds {
+		fmt.Fprintf(b, "%vpublic boolean is%s() {\n%vreturn value instanceof %s;\n%v}\n", indent, memberNames[i], in2, javaTypes[i], indent)
+		fmt.Fprintf(b, "%vpublic %s as%s() {\n%vreturn (%s) value;\n%v}\n", indent, javaTypes[i], memberNames[i], in2, javaTypes[i], indent)
+	}
+
+	fmt.Fprintf(b, "%vpublic static class Deserializer extends JsonDeserializer<%s> {\n", indent, name)
+	fmt.Fprintf(b, "%v@Override\n", in2)
+	fmt.Fprintf(b, "%vpublic %s deserialize(JsonParser p, DeserializationContext ctxt) throws IOException {\n", in2, name)
+	fmt.Fprintf(b, "%vswitch (p.currentToken()) {\n", in3)
+	for i, kind := range kinds {
+		switch kind {
+		case sumString:
+			fmt.Fprintf(b, "%vcase VALUE_STRING:\n", in3)
+		case sumInt:
+			fmt.Fprintf(b, "%vcase VALUE_NUMBER_INT:\n", in3)
+		case sumNumber:
+			fmt.Fprintf(b, "%vcase VALUE_NUMBER_INT:\n%vcase VALUE_NUMBER_FLOAT:\n", in3, in3)
+		case sumBool:
+			fmt.Fprintf(b, "%vcase VALUE_TRUE:\n%vcase VALUE_FALSE:\n", in3, in3)
+		}
+		fmt.Fprintf(b, "%v%vreturn new %s(%s);\n", in3, indent, name, readers[i])
+	}
+	fmt.Fprintf(b, "%vdefault:\n", in3)
+	fmt.Fprintf(b, "%v%vreturn (%s) ctxt.handleUnexpectedToken(%s.class, p);\n", in3, indent, name, name)
+	fmt.Fprintf(b, "%v}\n%v}\n%v}\n", in3, in2, indent)
+	fmt.Fprintf(b, "}")
+	options.sharedClasses[name] = b.String()
+	return name
+}
@@ -0,0 +1,402 @@
+package oojson
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Timestamp kinds counted in Value.TimestampFormats besides layouts.
+const (
+	TimestampUnix      = "unix"      // Unix time in seconds as a string
+	TimestampUnixMilli = "unixmilli" // Unix time in milliseconds as a string
+	TimestampDuration  = "duration"  // ISO 8601 duration
+)
+
+// Unix times between 2000 and 2100 are plausible timestamps.
+const (
+	minUnixSeconds = 946684800
+	maxUnixSeconds = 4102444800
+)
+
+var isoDurationRegexp = regexp.MustCompile(`^-?P(?:\d+(?:[.,]\d+)?Y)?(?:\d+(?:[.,]\d+)?M)?(?:\d+(?:[.,]\d+)?W)?(?:\d+(?:[.,]\d+)?D)?(?:T(?:\d+(?:[.,]\d+)?H)?(?:\d+(?:[.,]\d+)?M)?(?:\d+(?:[.,]\d+)?S)?)?$`)
+
+// A timeKind is the kind of time that a Value holds.
+type timeKind int
+
+// Time kinds.
+const (
+	timeDateTime  timeKind = iota // timestamps with a layout
+	timeDate                      // dates with a layout
+	timeDuration                  // ISO 8601 durations
+	timeUnix                      // Unix times in seconds
+	timeUnixMilli                 // Unix times in milliseconds
+)
+
+// A timestamp describes the times observed for a Value.
+type timestamp struct {
+	kind    timeKind
+	layout  string // layout of timeDateTime and timeDate
+	zone    bool   // whether the layout has a time zone
+	numeric bool   // whether Unix times are numbers rather than strings
+}
+
+// WithEpochTimestamps sets whether integers and numeric strings that are all
+// plausible Unix times in seconds or milliseconds become time types.
+func WithEpochTimestamps(enabled bool) Option {
+	return func(target optionTarget) error {
+		target.common().epochTimestamps = enabled
+		return nil
+	}
+}
+
+// timestamp returns the times of v, or nil if v is not only times and nulls.
+// Only layouts set by WithTimestampFormats are considered, and layouts that
+// encoding/json can parse take precedence over other layouts that all strings
+// matched.
+func (c *commonOption) timestamp(v *Value) *timestamp {
+	switch {
+	case v.Strings > 0 && v.Strings+v.Nulls == v.Observations:
+		if v.Times == v.Strings {
+			var layouts []string
+			for layout, n := range v.TimestampFormats {
+				if n == v.Strings && !isTimestampKind(layout) && c.isTimestampFormat(layout) {
+					layouts = append(layouts, layout)
+				}
+			}
+			if len(layouts) > 0 {
+				sort.Slice(layouts, func(i, j int) bool {
+					if iRFC3339, jRFC3339 := isRFC3339Layout(layouts[i]), isRFC3339Layout(layouts[j]); iRFC3339 != jRFC3339 {
+						return iRFC3339
+					}
+					return layouts[i] < layouts[j]
+				})
+				return newLayoutTimestamp(layouts[0])
+			}
+		}
+		switch {
+		case v.TimestampFormats[TimestampDuration] == v.Strings:
+			return &timestamp{kind: timeDuration}
+		case c.epochTimestamps && v.TimestampFormats[TimestampUnix] == v.Strings:
+			return &timestamp{kind: timeUnix}
+		case c.epochTimestamps && v.TimestampFormats[TimestampUnixMilli] == v.Strings:
+			return &timestamp{kind: timeUnixMilli}
+		}
+	case c.epochTimestamps && v.Ints > 0 && v.Ints+v.Nulls == v.Observations:
+		switch {
+		case v.UnixSeconds == v.Ints:
+			return &timestamp{kind: timeUnix, numeric: true}
+		case v.UnixMillis == v.Ints:
+			return &timestamp{kind: timeUnixMilli, numeric: true}
+		}
+	}
+	return nil
+}
+
+// newLayoutTimestamp returns the timestamp of strings with layout.
+func newLayoutTimestamp(layout string) *timestamp {
+	// Round trip a time with a time of day and a time zone to find out which
+	// the layout has.
+	t := time.Date(2001, 2, 3, 4, 5, 6, 0, time.FixedZone("", 3*60*60))
+	parsed, err := time.Parse(layout, t.Format(layout))
+	ts := &timestamp{kind: timeDateTime, layout: layout}
+	if err == nil {
+		_, offset := parsed.Zone()
+		ts.zone = offset != 0
+		if parsed.Hour() == 0 && parsed.Minute() == 0 && parsed.Second() == 0 {
+			ts.kind = timeDate
+		}
+	}
+	return ts
+}
+
+// isTimestampKind returns true if format is one of the timestamp kinds rather
+// than a layout.
+func isTimestampKind(format string) bool {
+	return format == TimestampUnix || format == TimestampUnixMilli || format == TimestampDuration
+}
+
+// isRFC3339Layout returns true if time.Time can unmarshal strings with layout.
+func isRFC3339Layout(layout string) bool {
+	return layout == time.RFC3339 || layout == time.RFC3339Nano
+}
+
+// observeTimestamp counts the timestamp layouts and kinds that s matches.
+func (o *Observer) observeTimestamp(v *Value, s string) {
+	if !strings.ContainsAny(s, "0123456789") {
+		return
+	}
+	matched := false
+	for _, layout := range o.TimestampFormats {
+		if _, err := time.Parse(layout, s); err != nil {
+			continue
+		}
+		countTimestampFormat(v, layout)
+		if v.TimestampFormat == "" {
+			v.TimestampFormat = layout
+		}
+		matched = true
+	}
+	if matched {
+		v.Times++
+	}
+	if len(s) > 2 && !strings.HasSuffix(s, "T") && isoDurationRegexp.MatchString(s) {
+		countTimestampFormat(v, TimestampDuration)
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		switch {
+		case i >= minUnixSeconds && i < maxUnixSeconds:
+			countTimestampFormat(v, TimestampUnix)
+		case i >= minUnixSeconds*1000 && i < maxUnixSeconds*1000:
+			countTimestampFormat(v, TimestampUnixMilli)
+		}
+	}
+}
+
+// countTimestampFormat counts a string of v that matched format.
+func countTimestampFormat(v *Value, format string) {
+	if v.TimestampFormats == nil {
+		v.TimestampFormats = make(map[string]int)
+	}
+	v.TimestampFormats[format]++
+}
+
+// observeUnixTime counts the integer i if it is a plausible Unix time.
+func observeUnixTime(v *Value, i int64) {
+	switch {
+	case i >= minUnixSeconds && i < maxUnixSeconds:
+		v.UnixSeconds++
+	case i >= minUnixSeconds*1000 && i < maxUnixSeconds*1000:
+		v.UnixMillis++
+	}
+}
+
+// isTimestampFormat returns true if properties observed with the layout
+// format should be generated as time types.
+func (c *commonOption) isTimestampFormat(format string) bool {
+	if c.timestampFormats == nil {
+		return true
+	}
+	for _, f := range c.timestampFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// goTimeType returns the Go type of the times ts and adds its import. Times
+// that time.Time and time.Duration cannot unmarshal get a declared wrapper
+// type.
+func (o *GoOption) goTimeType(ts *timestamp) ast.Expr {
+	switch {
+	case ts.kind == timeDate && ts.layout == time.DateOnly:
+		o.Imports["cloud.google.com/go/civil"] = struct{}{}
+		return ast.NewIdent("civil.Date")
+	case ts.kind == timeDuration:
+		return ast.NewIdent(o.declareGoISODuration())
+	case ts.kind == timeUnix, ts.kind == timeUnixMilli:
+		return ast.NewIdent(o.declareGoUnixTime(ts))
+	case isRFC3339Layout(ts.layout):
+		o.Imports["time"] = struct{}{}
+		return timeIdent
+	default:
+		return ast.NewIdent(o.declareGoTimeLayout(ts.layout))
+	}
+}
+
+// getJavaTimeType returns the Java type of the times ts and adds its import.
+func getJavaTimeType(ts *timestamp, options *JavaOption) string {
+	var javaType string
+	switch {
+	case ts.kind == timeDate:
+		javaType = "LocalDate"
+	case ts.kind == timeDuration:
+		javaType = "Duration"
+	case ts.kind == timeUnix, ts.kind == timeUnixMilli:
+		javaType = "Instant"
+	case ts.zone:
+		javaType = "OffsetDateTime"
+	default:
+		javaType = "LocalDateTime"
+	}
+	options.imports["java.time."+javaType] = struct{}{}
+	return javaType
+}
+
+// goTimeLayoutNames are the names of the wrapper types of common layouts.
+var goTimeLayoutNames = map[string]string{
+	time.DateOnly:         "Date",
+	time.DateTime:         "DateTime",
+	time.Layout:           "LayoutTime",
+	time.RFC822:           "RFC822Time",
+	time.RFC822Z:          "RFC822ZTime",
+	time.RFC850:           "RFC850Time",
+	time.RFC1123:          "RFC1123Time",
+	time.RFC1123Z:         "RFC1123ZTime",
+	"2006/01/02":          "DateSlash",
+	"2006/01/02 15:04:05": "DateTimeSlash",
+}
+
+// declareGoTimeLayout declares a time.Time wrapper that is marshalled in
+// layout, and returns its name. Each layout is declared once.
+func (o *GoOption) declareGoTimeLayout(layout string) string {
+	if typeName, ok := o.helperTypeNames[layout]; ok {
+		return typeName
+	}
+	name, ok := goTimeLayoutNames[layout]
+	if !ok {
+		name = "Timestamp"
+	}
+	typeName := o.reserveGoTypeName(name)
+	o.helperTypeNames[layout] = typeName
+
+	o.Imports["encoding/json"] = struct{}{}
+	o.Imports["time"] = struct{}{}
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "// %s is a time.Time in the layout %q.\n", typeName, layout)
+	fmt.Fprintf(b, "type %s time.Time\n\n", typeName)
+	fmt.Fprintf(b, "// UnmarshalJSON parses a time in the layout %q.\n", layout)
+	fmt.Fprintf(b, "func (t *%s) UnmarshalJSON(data []byte) error {\n", typeName)
+	fmt.Fprintf(b, "if string(data) == \"null\" {\nreturn nil\n}\n")
+	fmt.Fprintf(b, "var s string\nif err := json.Unmarshal(data, &s); err != nil {\nreturn err\n}\n")
+	fmt.Fprintf(b, "parsed, err := time.Parse(%q, s)\nif err != nil {\nreturn err\n}\n", layout)
+	fmt.Fprintf(b, "*t = %s(parsed)\nreturn nil\n}\n\n", typeName)
+	fmt.Fprintf(b, "// MarshalJSON formats t in the layout %q.\n", layout)
+	fmt.Fprintf(b, "func (t %s) MarshalJSON() ([]byte, error) {\n", typeName)
+	fmt.Fprintf(b, "return json.Marshal(time.Time(t).Format(%q))\n}\n", layout)
+	o.declareGoSource(b.String())
+	return typeName
+}
+
+// declareGoUnixTime declares a time.Time wrapper that is marshalled as the
+// Unix time of ts, and returns its name. Each kind of Unix time is declared
+// once.
+func (o *GoOption) declareGoUnixTime(ts *timestamp) string {
+	name, unit, toUnix, fromUnix := "UnixTime", "seconds", "Unix", "time.Unix(unix, 0)"
+	if ts.kind == timeUnixMilli {
+		name, unit, toUnix, fromUnix = "UnixMilliTime", "milliseconds", "UnixMilli", "time.UnixMilli(unix)"
+	}
+	if !ts.numeric {
+		name += "String"
+	}
+	if typeName, ok := o.helperTypeNames[name]; ok {
+		return typeName
+	}
+	typeName := o.reserveGoTypeName(name)
+	o.helperTypeNames[name] = typeName
+
+	o.Imports["strconv"] = struct{}{}
+	o.Imports["time"] = struct{}{}
+	b := &bytes.Buffer{}
+	encoding := "a number"
+	if !ts.numeric {
+		encoding = "a string"
+	}
+	fmt.Fprintf(b, "// %s is a time.Time encoded as %s of %s since the Unix epoch.\n", typeName, encoding, unit)
+	fmt.Fprintf(b, "type %s time.Time\n\n", typeName)
+	fmt.Fprintf(b, "// UnmarshalJSON parses %s since the Unix epoch.\n", unit)
+	fmt.Fprintf(b, "func (t *%s) UnmarshalJSON(data []byte) error {\n", typeName)
+	fmt.Fprintf(b, "if string(data) == \"null\" {\nreturn nil\n}\n")
+	if ts.numeric {
+		fmt.Fprintf(b, "unix, err := strconv.ParseInt(string(data), 10, 64)\n")
+	} else {
+		fmt.Fprintf(b, "s, err := strconv.Unquote(string(data))\nif err != nil {\nreturn err\n}\n")
+		fmt.Fprintf(b, "unix, err := strconv.ParseInt(s, 10, 64)\n")
+	}
+	fmt.Fprintf(b, "if err != nil {\nreturn err\n}\n")
+	fmt.Fprintf(b, "*t = %s(%s)\nreturn nil\n}\n\n", typeName, fromUnix)
+	fmt.Fprintf(b, "// MarshalJSON formats t as %s since the Unix epoch.\n", unit)
+	fmt.Fprintf(b, "func (t %s) MarshalJSON() ([]byte, error) {\n", typeName)
+	if ts.numeric {
+		fmt.Fprintf(b, "return strconv.AppendInt(nil, time.Time(t).%s(), 10), nil\n}\n", toUnix)
+	} else {
+		fmt.Fprintf(b, "return []byte(strconv.Quote(strconv.FormatInt(time.Time(t).%s(), 10))), nil\n}\n", toUnix)
+	}
+	o.declareGoSource(b.String())
+	return typeName
+}
+
+// goISODurationSource is the declaration of a time.Duration wrapper that is
+// marshalled as an ISO 8601 duration. %[1]s is its name.
+const goISODurationSource = `// %[1]s is a time.Duration encoded as an ISO 8601 duration, e.g. "PT5M".
+// Years are 365 days and months 30 days long.
+type %[1]s time.Duration
+
+// UnmarshalJSON parses an ISO 8601 duration.
+func (d *%[1]s) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	units := map[byte]time.Duration{'Y': 365 * 24 * time.Hour, 'M': 30 * 24 * time.Hour, 'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+	rest := strings.TrimPrefix(s, "-")
+	if !strings.HasPrefix(rest, "P") || len(rest) < 3 {
+		return fmt.Errorf("%[1]s: invalid ISO 8601 duration %%q", s)
+	}
+	var duration float64
+	number := ""
+	for i := 1; i < len(rest); i++ {
+		switch c := rest[i]; {
+		case c >= '0' && c <= '9' || c == '.' || c == ',':
+			number += string(c)
+		case c == 'T' && number == "":
+			units = map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+		default:
+			unit, ok := units[c]
+			f, err := strconv.ParseFloat(strings.Replace(number, ",", ".", 1), 64)
+			if !ok || err != nil {
+				return fmt.Errorf("%[1]s: invalid ISO 8601 duration %%q", s)
+			}
+			duration += f * float64(unit)
+			number = ""
+		}
+	}
+	if number != "" {
+		return fmt.Errorf("%[1]s: invalid ISO 8601 duration %%q", s)
+	}
+	if strings.HasPrefix(s, "-") {
+		duration = -duration
+	}
+	*d = %[1]s(duration)
+	return nil
+}
+
+// MarshalJSON formats d as an ISO 8601 duration in hours, minutes and seconds.
+func (d %[1]s) MarshalJSON() ([]byte, error) {
+	duration, sign := time.Duration(d), ""
+	if duration < 0 {
+		duration, sign = -duration, "-"
+	}
+	hours := int64(duration / time.Hour)
+	minutes := int64(duration %% time.Hour / time.Minute)
+	seconds := strconv.FormatFloat((duration %% time.Minute).Seconds(), 'f', -1, 64)
+	return json.Marshal(fmt.Sprintf("%%sPT%%dH%%dM%%sS", sign, hours, minutes, seconds))
+}
+`
+
+// declareGoISODuration declares the ISO 8601 duration wrapper the first time
+// it is used, and returns its name.
+func (o *GoOption) declareGoISODuration() string {
+	if typeName, ok := o.helperTypeNames[TimestampDuration]; ok {
+		return typeName
+	}
+	typeName := o.reserveGoTypeName("ISODuration")
+	o.helperTypeNames[TimestampDuration] = typeName
+
+	o.Imports["encoding/json"] = struct{}{}
+	o.Imports["fmt"] = struct{}{}
+	o.Imports["strconv"] = struct{}{}
+	o.Imports["strings"] = struct{}{}
+	o.Imports["time"] = struct{}{}
+	o.declareGoSource(fmt.Sprintf(goISODurationSource, typeName))
+	return typeName
+}
@@ -0,0 +1,77 @@
+package oojson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestISODurationRoundTrip(t *testing.T) {
+	output := runGoFile(t, `{"timeout":"PT5M"}`, `import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+func main() {
+	var root Root
+	if err := json.Unmarshal([]byte(`+"`"+`{"timeout":"P1DT1H30M0.5S"}`+"`"+`), &root); err != nil {
+		panic(err)
+	}
+	data, err := json.Marshal(root)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(time.Duration(root.Timeout), string(data))
+}
+`)
+	if expected := `25h30m0.5s {"timeout":"PT25H30M0.5S"}`; strings.TrimSpace(output) != expected {
+		t.Errorf("expected %s, got %s", expected, output)
+	}
+}
+
+func TestJavaUnixMilliFormat(t *testing.T) {
+	v := observeJSON(t, `{"at":1700000000000,"seconds":1700000000}`)
+	_, code := GetJavaType(v, "Event", "  ", DefaultJavaOption(WithEpochTimestamps(true)))
+	annotation := "  @JsonFormat(without = {JsonFormat.Feature.READ_DATE_TIMESTAMPS_AS_NANOSECONDS, JsonFormat.Feature.WRITE_DATE_TIMESTAMPS_AS_NANOSECONDS})\n  private Instant at;"
+	if !strings.Contains(code, annotation) || strings.Count(code, "@JsonFormat") != 1 {
+		t.Errorf("expected only the milliseconds field to be annotated, got\n%s", code)
+	}
+}
+
+func TestGoTimeWrappers(t *testing.T) {
+	data := `{"day":"2024/01/02","at":"2024/01/02 03:04:05","unix":1700000000,"ms":1700000000123,"rfc":"2024-01-02T03:04:05Z"}`
+	src := generateGoFile(t, data, WithEpochTimestamps(true))
+	for _, want := range []string{
+		"At   DateTimeSlash", "Day  DateSlash", "Ms   UnixMilliTime", "Rfc  time.Time", "Unix UnixTime",
+		"type DateSlash time.Time", "type UnixMilliTime time.Time",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("expected %q, got\n%s", want, src)
+		}
+	}
+
+	output := runGoFile(t, data, `import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+func main() {
+	var root Root
+	if err := json.Unmarshal([]byte(`+"`"+data+"`"+`), &root); err != nil {
+		panic(err)
+	}
+	encoded, err := json.Marshal(root)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(time.Time(root.At).UTC().Format(time.RFC3339), time.Time(root.Ms).UTC().Format(time.RFC3339Nano))
+	fmt.Println(string(encoded))
+}
+`, WithEpochTimestamps(true))
+	want := "2024-01-02T03:04:05Z 2023-11-14T22:13:20.123Z\n" +
+		`{"at":"2024/01/02 03:04:05","day":"2024/01/02","ms":1700000000123,"rfc":"2024-01-02T03:04:05Z","unix":1700000000}`
+	if strings.TrimSpace(output) != want {
+		t.Errorf("expected %s, got %s", want, output)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/iancoleman/strcase"
 	"golang.org/x/exp/maps"
@@ -14,30 +16,48 @@ const (
 	TsNumber = "number"
 	TsAny    = "any"
 	TsString = "string"
+	TsBigInt = "bigint"
 )
 
 type TsOption struct {
-	exportNameFunc ExportNameFunc
-	imports        map[string]struct{}
-	exportRenames  map[string]string
+	commonOption
+	imports map[string]struct{}
+	brands  map[string]bool // branded types declared so far
 }
 
-func DefaultTsOption() *TsOption {
+// NewTsOption returns the default TsOption with opts applied, or an error if
+// any of opts is invalid.
+func NewTsOption(opts ...Option) (*TsOption, error) {
 	opt := &TsOption{
 		imports: make(map[string]struct{}),
 	}
 
-	opt.exportNameFunc = func(name string) string {
-		if rename, ok := opt.exportRenames[name]; ok {
-			return rename
-		}
-		return strcase.ToLowerCamel(name)
+	opt.exportNameFunc = strcase.ToLowerCamel
+	if err := applyOptions(opt, opts); err != nil {
+		return nil, err
+	}
+	return opt, nil
+}
+
+// DefaultTsOption is like NewTsOption but panics if any of opts is invalid.
+func DefaultTsOption(opts ...Option) *TsOption {
+	opt, err := NewTsOption(opts...)
+	if err != nil {
+		panic(err)
 	}
 	return opt
 }
 
-// goType returns the Go type of v.
+// GetTsType returns the TypeScript type of v, which is named name if it is an
+// object, and the code of the types it declares.
 func GetTsType(v *Value, name string, indent string, options *TsOption) (string, string) {
+	options.unifyShapes(v)
+	options.resolveOverrides(v)
+	options.brands = make(map[string]bool)
+	return getTsType(v, name, indent, options)
+}
+
+func getTsType(v *Value, name string, indent string, options *TsOption) (string, string) {
 	// Determine the number of distinct types observed.
 	distinctTypes := 0
 	if v.Arrays > 0 {
@@ -63,16 +83,27 @@ func GetTsType(v *Value, name string, indent string, options *TsOption) (string,
 	}
 
 	subClasses := map[string]string{}
+	e := options.enum(v)
+	ts := options.timestamp(v)
+	sum := options.sumType(v)
 
 	// Based on the observed distinct types, find the most specific Go type.
 	switch {
+	case e != nil:
+		return getTsEnum(e), ""
 	case distinctTypes == 1 && v.Arrays > 0:
 		fallthrough
 	case distinctTypes == 2 && v.Arrays > 0 && v.Nulls > 0:
-		elementType, customDefinition := GetTsType(v.ArrayElements, name, indent, options)
+		if indexes, heterogeneous := options.tuple(v); indexes != nil {
+			return getTsTuple(v, indexes, heterogeneous, name, indent, options)
+		}
+		elementType, customDefinition := getTsType(v.ArrayElements, name, indent, options)
 		if len(customDefinition) > 0 {
 			subClasses[elementType] = customDefinition
 		}
+		if strings.Contains(elementType, " | ") {
+			elementType = "(" + elementType + ")"
+		}
 		return fmt.Sprintf("%v[]", elementType), customDefinition
 	case distinctTypes == 1 && v.Bools > 0:
 		return TsBool, ""
@@ -83,9 +114,9 @@ func GetTsType(v *Value, name string, indent string, options *TsOption) (string,
 	case distinctTypes == 2 && v.Float64s > 0 && v.Nulls > 0:
 		return TsNumber, ""
 	case distinctTypes == 1 && v.Ints > 0:
-		return TsNumber, ""
+		return getTsIntType(v), ""
 	case distinctTypes == 2 && v.Ints > 0 && v.Nulls > 0:
-		return TsNumber, ""
+		return getTsIntType(v), ""
 	case distinctTypes == 2 && v.Float64s > 0 && v.Ints > 0:
 		return TsNumber, ""
 	case distinctTypes == 3 && v.Float64s > 0 && v.Ints > 0 && v.Nulls > 0:
@@ -96,43 +127,159 @@ func GetTsType(v *Value, name string, indent string, options *TsOption) (string,
 		if len(v.ObjectProperties) == 0 {
 			return TsAny, ""
 		}
-		b := &bytes.Buffer{}
-		properties := maps.Keys(v.ObjectProperties)
-		sort.Strings(properties)
-		fmt.Fprintf(b, "type %v = {\n", name)
-		var unparseableProperties []string
-		for _, property := range properties {
-			if isUnparsableProperty(property) {
-				unparseableProperties = append(unparseableProperties, property)
-				continue
-			}
-
-			subClassType, customCode := GetTsType(v.ObjectProperties[property], strcase.ToCamel(property), indent, options)
-			if len(customCode) > 0 {
-				subClasses[subClassType] = customCode
+		if keyKind := options.keyKind(v); keyKind != keysStatic {
+			keyType := TsString
+			if keyKind == keysInt {
+				keyType = TsNumber
 			}
-			fmt.Fprintf(b, "%v%s: %s;\n", indent, strcase.ToLowerCamel(property), subClassType)
+			valueType, customDefinition := getTsType(v.AllObjectProperties, singularize(name), indent, options)
+			return fmt.Sprintf("Record<%s, %s>", keyType, valueType), customDefinition
 		}
-		fmt.Fprintf(b, "}\n\n")
-
-		for _, property := range unparseableProperties {
-			fmt.Fprintf(b, "// %q cannot be unmarshalled into a struct field by encoding/json.\n", property)
+		if u := options.union(v); u != nil {
+			return getTsUnion(u, name, indent, options)
 		}
-
-		for _, code := range subClasses {
-			fmt.Fprintf(b, "%s\n", code)
+		shape, shared := options.shapes.shapeOf(v)
+		if typeName, ok := options.shapeNames[shape]; ok {
+			return typeName, ""
 		}
-
-		return name, b.String()
-	case distinctTypes == 1 && v.Strings > 0 && v.Times == v.Strings:
+		if shared {
+			name = options.shapes.name(shape, name)
+			options.shapeNames[shape] = name
+		}
+		return name, getTsObject(shape, name, indent, nil, options)
+	case distinctTypes == 1 && v.Strings > 0 && ts != nil:
 		return TsString, ""
 	case distinctTypes == 1 && v.Strings > 0:
-		return TsString, ""
-	case distinctTypes == 2 && v.Strings > 0 && v.Nulls > 0 && v.Times == v.Strings:
+		return getTsStringType(v, options)
+	case distinctTypes == 2 && v.Strings > 0 && v.Nulls > 0 && ts != nil:
 		return TsString, ""
 	case distinctTypes == 2 && v.Strings > 0 && v.Nulls > 0:
-		return TsString, ""
+		return getTsStringType(v, options)
+	case sum != nil:
+		return getTsSumType(v, sum), ""
 	default:
 		return TsAny, ""
 	}
 }
+
+// getTsObject returns the code of an object type named name with a property
+// for each property of v, followed by the types it declares. literals maps
+// properties to literal types that replace their observed types.
+func getTsObject(v *Value, name string, indent string, literals map[string]string, options *TsOption) string {
+	subClasses := map[string]string{}
+	b := &bytes.Buffer{}
+	writeTsObject(b, v, name, indent, literals, subClasses, options)
+	writeTsSubClasses(b, subClasses)
+	return b.String()
+}
+
+// writeTsObject writes the code of the object type of getTsObject to b, and
+// adds the code of the types it declares to subClasses by name.
+func writeTsObject(b *bytes.Buffer, v *Value, name string, indent string, literals map[string]string, subClasses map[string]string, options *TsOption) {
+	properties := options.properties(v)
+	fieldNames := options.fieldNames(v, properties, options.exportName)
+	fmt.Fprintf(b, "type %v = {\n", name)
+	var unparseableProperties []string
+	for _, property := range properties {
+		if isUnparsableProperty(property) {
+			unparseableProperties = append(unparseableProperties, property)
+			continue
+		}
+
+		override := options.override(v, property)
+		if override.Skip {
+			continue
+		}
+		subClassType, ok := literals[property]
+		switch {
+		case ok:
+		case override.TsType != "":
+			subClassType = override.TsType
+		default:
+			var customCode string
+			subClassType, customCode = getTsType(v.ObjectProperties[property], strcase.ToCamel(property), indent, options)
+			if len(customCode) > 0 {
+				subClasses[subClassType] = customCode
+			}
+		}
+		value := override.presence(v.ObjectProperties[property])
+		optional := ""
+		if value.Absents > 0 {
+			optional = "?"
+		}
+		if value.Nulls > 0 && subClassType != TsAny {
+			subClassType += " | null"
+		}
+		fmt.Fprintf(b, "%v%s%s: %s;\n", indent, fieldNames[property], optional, subClassType)
+	}
+	fmt.Fprintf(b, "}\n\n")
+
+	for _, property := range unparseableProperties {
+		fmt.Fprintf(b, "// %q cannot be unmarshalled into a struct field by encoding/json.\n", property)
+	}
+}
+
+// writeTsSubClasses writes the code of the types subClasses to b, sorted by
+// name.
+func writeTsSubClasses(b *bytes.Buffer, subClasses map[string]string) {
+	subClassNames := maps.Keys(subClasses)
+	sort.Strings(subClassNames)
+	for _, subClassName := range subClassNames {
+		fmt.Fprintf(b, "%s\n", subClasses[subClassName])
+	}
+}
+
+// tsBrands are the names of the branded types of semantic string formats.
+var tsBrands = map[string]string{
+	FormatUUID:     "UUID",
+	FormatEmail:    "Email",
+	FormatURI:      "URI",
+	FormatIPv4:     "IPv4",
+	FormatIPv6:     "IPv6",
+	FormatSemver:   "SemVer",
+	FormatHostname: "Hostname",
+	FormatHex:      "Hex",
+	FormatBase64:   "Base64",
+}
+
+// getTsStringType returns the TypeScript type of the strings observed for v.
+// Strings with a semantic format get a branded type, which is declared the
+// first time it is used.
+func getTsStringType(v *Value, options *TsOption) (string, string) {
+	format := options.stringFormat(v)
+	if format == "" {
+		return TsString, ""
+	}
+	brand, ok := tsBrands[format]
+	if !ok {
+		brand = strcase.ToCamel(format)
+	}
+	if options.brands[brand] {
+		return brand, ""
+	}
+	options.brands[brand] = true
+	return brand, fmt.Sprintf("type %s = string & { readonly __brand: %q };\n", brand, format)
+}
+
+// getTsIntType returns bigint if some integers observed for v cannot be
+// represented exactly by number, and number otherwise. JSON.parse never
+// returns a bigint, so bigint properties must be decoded with a parser that
+// returns them, such as json-bigint with useNativeBigInt.
+func getTsIntType(v *Value) string {
+	if v.UnsafeInts > 0 {
+		return TsBigInt
+	}
+	return TsNumber
+}
+
+// getTsEnum returns the union of the literal types of the values of e.
+func getTsEnum(e *enum) string {
+	var literals []string
+	for _, value := range e.strings {
+		literals = append(literals, strconv.Quote(value))
+	}
+	for _, value := range e.ints {
+		literals = append(literals, strconv.FormatInt(value, 10))
+	}
+	return strings.Join(literals, " | ")
+}
@@ -0,0 +1,116 @@
+package oojson
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// WithTuples sets whether arrays that always have the same length of at least
+// two become tuples. Arrays whose elements have the same kind become fixed-size
+// arrays. Arrays whose elements have a different kind at each index become
+// positional types. Arrays must have been observed twice, and at most
+// Observer.MaxTupleLength elements long.
+func WithTuples(enabled bool) Option {
+	return func(target optionTarget) error {
+		target.common().tuples = enabled
+		return nil
+	}
+}
+
+// tuple returns the values observed at each index of the arrays of v if they
+// are tuples, and whether their elements are heterogeneous.
+func (c *commonOption) tuple(v *Value) ([]*Value, bool) {
+	if !c.tuples || v.Arrays < 2 || v.MinArrayLength < 2 || v.MinArrayLength != v.MaxArrayLength ||
+		len(v.ArrayIndexes) != v.MaxArrayLength {
+		return nil, false
+	}
+	heterogeneous := false
+	for _, index := range v.ArrayIndexes {
+		kinds := valueKinds(index)
+		if kinds == 0 || kinds&(kinds-1) != 0 {
+			return nil, false
+		}
+		if kinds != valueKinds(v.ArrayIndexes[0]) {
+			heterogeneous = true
+		}
+	}
+	return v.ArrayIndexes, heterogeneous
+}
+
+// getGoTupleAst returns the Go type of tuples with the values at each index of
+// indexes, which is an array if they are homogeneous and a declared positional
+// struct otherwise.
+func getGoTupleAst(v *Value, indexes []*Value, heterogeneous bool, name string, options *GoOption) ast.Expr {
+	if !heterogeneous {
+		elementGoType, _ := getGoAst(v.ArrayElements, 0, singularize(name), options)
+		return &ast.ArrayType{
+			Len: &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(len(indexes))},
+			Elt: elementGoType,
+		}
+	}
+	return ast.NewIdent(declareGoTuple(indexes, name, options))
+}
+
+// declareGoTuple declares a struct with a field for each index of indexes
+// that is marshalled as a JSON array, and returns its name.
+func declareGoTuple(indexes []*Value, name string, options *GoOption) string {
+	if name == "" {
+		name = "Tuple"
+	}
+	typeSpec := options.declareGoType(name)
+	typeName := typeSpec.Name.Name
+
+	fields := &ast.FieldList{}
+	var fieldNames, fieldPointers []string
+	for i, index := range indexes {
+		fieldName := "Field" + strconv.Itoa(i)
+		fieldGoType, _ := getGoAst(index, 0, typeName+fieldName, options)
+		fields.List = append(fields.List, &ast.Field{
+			Names: []*ast.Ident{ast.NewIdent(fieldName)},
+			Type:  fieldGoType,
+		})
+		fieldNames = append(fieldNames, "t."+fieldName)
+		fieldPointers = append(fieldPointers, "&t."+fieldName)
+	}
+	typeSpec.Type = &ast.StructType{Fields: fields}
+
+	options.Imports["encoding/json"] = struct{}{}
+	options.Imports["fmt"] = struct{}{}
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "// UnmarshalJSON decodes a JSON array of %d elements into t.\n", len(indexes))
+	fmt.Fprintf(b, "func (t *%s) UnmarshalJSON(data []byte) error {\n", typeName)
+	fmt.Fprintf(b, "elements := []any{%s}\n", strings.Join(fieldPointers, ", "))
+	fmt.Fprintf(b, "if err := json.Unmarshal(data, &elements); err != nil {\nreturn err\n}\n")
+	fmt.Fprintf(b, "if len(elements) != %d {\n", len(indexes))
+	fmt.Fprintf(b, "return fmt.Errorf(\"%s: expected %d elements, got %%d\", len(elements))\n}\n", typeName, len(indexes))
+	fmt.Fprintf(b, "return nil\n}\n\n")
+	fmt.Fprintf(b, "// MarshalJSON encodes t as a JSON array.\n")
+	fmt.Fprintf(b, "func (t %s) MarshalJSON() ([]byte, error) {\n", typeName)
+	fmt.Fprintf(b, "return json.Marshal([]any{%s})\n}\n", strings.Join(fieldNames, ", "))
+	options.declareGoSource(b.String())
+	return typeName
+}
+
+// getTsTuple returns the TypeScript tuple type with the values at each index
+// of indexes, and the code of the types it declares.
+func getTsTuple(v *Value, indexes []*Value, heterogeneous bool, name string, indent string, options *TsOption) (string, string) {
+	elementTypes := make([]string, len(indexes))
+	if !heterogeneous {
+		elementType, customDefinition := getTsType(v.ArrayElements, name, indent, options)
+		for i := range elementTypes {
+			elementTypes[i] = elementType
+		}
+		return "[" + strings.Join(elementTypes, ", ") + "]", customDefinition
+	}
+	b := &strings.Builder{}
+	for i, index := range indexes {
+		elementType, customDefinition := getTsType(index, name+strconv.Itoa(i), indent, options)
+		elementTypes[i] = elementType
+		b.WriteString(customDefinition)
+	}
+	return "[" + strings.Join(elementTypes, ", ") + "]", b.String()
+}
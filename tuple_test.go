@@ -0,0 +1,45 @@
+package oojson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTuples(t *testing.T) {
+	data := `{"trace":[[0.5,0.5],[0.1,0.2]],"rows":[[1,"a",true],[2,"b",false]],"lists":[[1],[2,3]]}`
+	src := generateGoFile(t, data, WithTuples(true))
+	for _, want := range []string{"Trace [][2]float64", "Rows  []Row", "Lists [][]int", "type Row struct {\n\tField0 int\n\tField1 string\n\tField2 bool\n}"} {
+		if !strings.Contains(src, want) {
+			t.Errorf("expected %q, got\n%s", want, src)
+		}
+	}
+
+	v := observeJSON(t, data)
+	_, tsCode := GetTsType(v, "Root", "  ", DefaultTsOption(WithTuples(true)))
+	for _, want := range []string{"rows: [number, string, boolean][];", "trace: [number, number][];", "lists: number[][];"} {
+		if !strings.Contains(tsCode, want) {
+			t.Errorf("expected %s in TypeScript, got\n%s", want, tsCode)
+		}
+	}
+
+	output := runGoFile(t, data, `import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var root Root
+	if err := json.Unmarshal([]byte(`+"`"+data+"`"+`), &root); err != nil {
+		panic(err)
+	}
+	encoded, err := json.Marshal(root.Rows)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(root.Rows[1].Field1, string(encoded))
+}
+`, WithTuples(true))
+	if want := `b [[1,"a",true],[2,"b",false]]`; strings.TrimSpace(output) != want {
+		t.Errorf("expected %s, got %s", want, output)
+	}
+}
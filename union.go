@@ -0,0 +1,196 @@
+package oojson
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"golang.org/x/exp/maps"
+)
+
+// A union is a discriminated union of objects whose variants are selected by
+// the string value of a discriminator property.
+type union struct {
+	property string            // discriminator property
+	values   []string          // discriminator values in sorted order
+	variants map[string]*Value // objects observed for each value
+}
+
+// WithUnions sets whether objects whose properties depend on the value of a
+// discriminator property, such as "type", become discriminated unions.
+// Discriminators are the properties tracked by Observer.Discriminators that
+// all objects have as a string, with at least two values whose objects have
+// different properties.
+func WithUnions(enabled bool) Option {
+	return func(target optionTarget) error {
+		target.common().unions = enabled
+		return nil
+	}
+}
+
+// union returns the discriminated union of the objects of v, or nil if unions
+// are disabled or v has no discriminator.
+func (c *commonOption) union(v *Value) *union {
+	if !c.unions {
+		return nil
+	}
+	properties := maps.Keys(v.Variants)
+	sort.Strings(properties)
+	for _, property := range properties {
+		variants := v.Variants[property]
+		discriminator := v.ObjectProperties[property]
+		if len(variants) < 2 || discriminator == nil || discriminator.Strings != v.Objects || !hasDistinctShapes(variants) {
+			continue
+		}
+		values := maps.Keys(variants)
+		sort.Strings(values)
+		return &union{property: property, values: values, variants: variants}
+	}
+	return nil
+}
+
+// hasDistinctShapes returns true if some variants have different properties.
+func hasDistinctShapes(variants map[string]*Value) bool {
+	var shape string
+	first := true
+	for _, variant := range variants {
+		properties := maps.Keys(variant.ObjectProperties)
+		sort.Strings(properties)
+		variantShape := strings.Join(properties, "\x00")
+		if !first && variantShape != shape {
+			return true
+		}
+		shape, first = variantShape, false
+	}
+	return false
+}
+
+// variantNames returns the names of the variants of u, which are name followed
+// by the export name of their discriminator value, made unique with numeric
+// suffixes.
+func (u *union) variantNames(name string, exportName ExportNameFunc) map[string]string {
+	names := make(map[string]string)
+	used := make(map[string]bool)
+	for _, value := range u.values {
+		base := name + exportName(value)
+		variantName := base
+		for i := 2; used[variantName]; i++ {
+			variantName = base + strconv.Itoa(i)
+		}
+		used[variantName] = true
+		names[value] = variantName
+	}
+	return names
+}
+
+// declareGoUnion declares an interface that the variants of u implement, a
+// struct for each variant and a type named after name that holds a variant and
+// decodes the one selected by the discriminator. It returns the name of the
+// latter.
+func declareGoUnion(u *union, name string, options *GoOption) string {
+	var typeName string
+	switch {
+	case name == "" && options.rootTypeName != "":
+		typeName = options.rootTypeName
+	case name == "":
+		typeName = options.reserveGoTypeName("Union")
+	default:
+		typeName = options.reserveGoTypeName(name)
+	}
+	interfaceName := options.reserveGoTypeName(typeName + "Variant")
+	variantNames := make(map[string]string)
+	for _, value := range u.values {
+		variantNames[value] = options.reserveGoTypeName(typeName + options.exportNameFunc(value))
+	}
+
+	options.Imports["encoding/json"] = struct{}{}
+	options.Imports["fmt"] = struct{}{}
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "// %s holds the %s selected by the %q property.\n", typeName, interfaceName, u.property)
+	fmt.Fprintf(b, "type %s struct {\nValue %s\n}\n\n", typeName, interfaceName)
+	fmt.Fprintf(b, "// UnmarshalJSON decodes the variant selected by the %q property into u.\n", u.property)
+	fmt.Fprintf(b, "func (u *%s) UnmarshalJSON(data []byte) error {\n", typeName)
+	fmt.Fprintf(b, "var discriminator struct {\nValue string `json:%q`\n}\n", u.property)
+	fmt.Fprintf(b, "if err := json.Unmarshal(data, &discriminator); err != nil {\nreturn err\n}\n")
+	fmt.Fprintf(b, "switch discriminator.Value {\n")
+	for _, value := range u.values {
+		fmt.Fprintf(b, "case %q:\nu.Value = &%s{}\n", value, variantNames[value])
+	}
+	fmt.Fprintf(b, "default:\nreturn fmt.Errorf(\"%s: unknown %s %%q\", discriminator.Value)\n}\n", typeName, u.property)
+	fmt.Fprintf(b, "return json.Unmarshal(data, u.Value)\n}\n\n")
+	fmt.Fprintf(b, "// MarshalJSON encodes the variant of u.\n")
+	fmt.Fprintf(b, "func (u %s) MarshalJSON() ([]byte, error) {\nreturn json.Marshal(u.Value)\n}\n\n", typeName)
+	fmt.Fprintf(b, "// %s is implemented by the variants of %s.\n", interfaceName, typeName)
+	fmt.Fprintf(b, "type %s interface {\nis%s()\n}\n", interfaceName, typeName)
+	options.declareGoSource(b.String())
+
+	for _, value := range u.values {
+		typeSpec := &ast.TypeSpec{Name: ast.NewIdent(variantNames[value])}
+		options.Decls = append(options.Decls, &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{typeSpec}})
+		options.declareGoSource(fmt.Sprintf("func (%s) is%s() {}\n", variantNames[value], typeName))
+		typeSpec.Type = getGoStructAst(u.variants[value], variantNames[value], options)
+	}
+	return typeName
+}
+
+// getTsUnion returns a union of object types, one for each variant of u with
+// the discriminator property typed as its value, and the code of the types it
+// declares. Types that several variants declare are declared once.
+func getTsUnion(u *union, name string, indent string, options *TsOption) (string, string) {
+	if name == "" {
+		name = "Union"
+	}
+	variantNames := u.variantNames(name, strcase.ToCamel)
+	var variantTypes []string
+	for _, value := range u.values {
+		variantTypes = append(variantTypes, variantNames[value])
+	}
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "type %s = %s;\n\n", name, strings.Join(variantTypes, " | "))
+	subClasses := map[string]string{}
+	for _, value := range u.values {
+		literals := map[string]string{u.property: strconv.Quote(value)}
+		writeTsObject(b, u.variants[value], variantNames[value], indent, literals, subClasses, options)
+	}
+	writeTsSubClasses(b, subClasses)
+	return name, b.String()
+}
+
+// getJavaUnion returns an abstract class named name with a subclass for each
+// variant of u, which Jackson selects by the discriminator property, and its
+// code.
+func getJavaUnion(u *union, name string, indent string, root bool, options *JavaOption) (string, string) {
+	if name == "" {
+		name = "Union"
+	}
+	options.imports["com.fasterxml.jackson.annotation.JsonSubTypes"] = struct{}{}
+	options.imports["com.fasterxml.jackson.annotation.JsonTypeInfo"] = struct{}{}
+	variantNames := u.variantNames(name, strcase.ToCamel)
+
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "@JsonTypeInfo(use = JsonTypeInfo.Id.NAME, include = JsonTypeInfo.As.EXISTING_PROPERTY, property = %q, visible = true)\n", u.property)
+	fmt.Fprintf(b, "@JsonSubTypes({\n")
+	for _, value := range u.values {
+		fmt.Fprintf(b, "%v@JsonSubTypes.Type(value = %s.%s.class, name = %q),\n", indent, name, variantNames[value], value)
+	}
+	fmt.Fprintf(b, "})\n")
+	fmt.Fprintf(b, "abstract class %s {\n", name)
+	subClasses := map[string]string{}
+	for _, value := range u.values {
+		variantName, code := getJavaType(u.variants[value], variantNames[value], indent, false, options)
+		subClasses[variantName] = strings.Replace(code, "class "+variantName+" {", "class "+variantName+" extends "+name+" {", 1)
+	}
+	if root {
+		for sharedName, code := range options.sharedClasses {
+			subClasses[sharedName] = code
+		}
+	}
+	writeJavaSubClasses(b, subClasses, indent)
+	fmt.Fprintf(b, "}")
+	return name, b.String()
+}
@@ -0,0 +1,28 @@
+package oojson
+
+import (
+	"strings"
+	"testing"
+)
+
+const unionSample = `[{"type":"card","meta":{"id":1},"billing_address":{"city":"a"}},` +
+	`{"type":"bank","meta":{"id":2},"billing_address":{"city":"b"},"iban":"x"}]`
+
+func TestTsUnionDeclaresNestedTypesOnce(t *testing.T) {
+	v := observeJSON(t, unionSample)
+	_, code := GetTsType(v, "Payment", "  ", DefaultTsOption(WithUnions(true)))
+	for _, declaration := range []string{"type Meta =", "type BillingAddress ="} {
+		if n := strings.Count(code, declaration); n != 1 {
+			t.Errorf("expected %q once, got %d times in\n%s", declaration, n, code)
+		}
+	}
+}
+
+func TestUnionVariantsShareTypes(t *testing.T) {
+	src := generateGoFile(t, unionSample, WithUnions(true), WithSharedTypes(0.5))
+	for _, field := range []string{"Meta Meta", "BillingAddress BillingAddress"} {
+		if n := strings.Count(strings.Join(strings.Fields(src), " "), field); n != 2 {
+			t.Errorf("expected %q in both variants, got %d in\n%s", field, n, src)
+		}
+	}
+}
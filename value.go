@@ -1,8 +1,16 @@
 package oojson
 
 import (
+	"bytes"
 	"encoding/json"
-	"time"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/maps"
 )
 
 // An OmitEmptyOption is an option for handling omitempty.
@@ -20,30 +28,144 @@ type ExportNameFunc func(string) string
 
 // An Value describes an observed Value.
 type Value struct {
-	Observations        int
-	Emptys              int
-	Arrays              int
-	Bools               int
-	Float64s            int
-	Ints                int
-	Nulls               int
-	Objects             int
-	Strings             int
-	Times               int // time.Time is an implicit more specific type than string.
-	TimestampFormat     string
-	ArrayElements       *Value
-	AllObjectProperties *Value
-	ObjectProperties    map[string]*Value
+	Observations        int                          `json:",omitempty"`
+	Emptys              int                          `json:",omitempty"` // empty arrays, objects and strings, false and zero
+	Zeros               int                          `json:",omitempty"` // false, zero and empty strings
+	Absents             int                          `json:",omitempty"` // objects that did not have the property
+	Arrays              int                          `json:",omitempty"`
+	Bools               int                          `json:",omitempty"`
+	Float64s            int                          `json:",omitempty"`
+	Ints                int                          `json:",omitempty"`
+	Nulls               int                          `json:",omitempty"`
+	Objects             int                          `json:",omitempty"`
+	Strings             int                          `json:",omitempty"`
+	Times               int                          `json:",omitempty"` // strings that matched a timestamp layout
+	StringInts          int                          `json:",omitempty"` // strings that encode integers, e.g. "12345"
+	StringFloats        int                          `json:",omitempty"` // strings that encode numbers, including integers
+	StringBools         int                          `json:",omitempty"` // strings "true" and "false"
+	StringZeros         int                          `json:",omitempty"` // strings that encode zero or false
+	MinStringInt        int64                        `json:",omitempty"` // smallest integer encoded by a string
+	MaxStringInt        int64                        `json:",omitempty"` // largest integer encoded by a string
+	TimestampFormat     string                       `json:",omitempty"` // first timestamp layout that matched
+	TimestampFormats    map[string]int               `json:",omitempty"` // strings matching each timestamp layout, TimestampUnix, TimestampUnixMilli or TimestampDuration
+	UnixSeconds         int                          `json:",omitempty"` // integers that are plausible Unix times in seconds
+	UnixMillis          int                          `json:",omitempty"` // integers that are plausible Unix times in milliseconds
+	StringValues        map[string]int               `json:",omitempty"` // distinct strings and their counts
+	IntValues           map[int64]int                `json:",omitempty"` // distinct integers and their counts
+	TooManyValues       bool                         `json:",omitempty"` // more distinct values than tracked were observed
+	MinNumber           float64                      `json:",omitempty"` // smallest number observed
+	MaxNumber           float64                      `json:",omitempty"` // largest number observed
+	Negatives           int                          `json:",omitempty"` // numbers less than zero
+	UnsafeInts          int                          `json:",omitempty"` // integers beyond ±2^53, where float64 loses precision
+	Uint64s             int                          `json:",omitempty"` // integers beyond int64 that fit in uint64
+	BigInts             int                          `json:",omitempty"` // integers that fit in neither int64 nor uint64
+	Formats             map[string]int               `json:",omitempty"` // strings matching each semantic format
+	MinArrayLength      int                          `json:",omitempty"` // length of the shortest array observed
+	MaxArrayLength      int                          `json:",omitempty"` // length of the longest array observed
+	ArrayIndexes        []*Value                     `json:",omitempty"` // elements at each index, if no array was longer than tracked
+	ArrayElements       *Value                       `json:",omitempty"`
+	AllObjectProperties *Value                       `json:",omitempty"`
+	ObjectProperties    map[string]*Value            `json:",omitempty"`
+	PropertyOrder       []string                     `json:",omitempty"` // properties in the order they were first observed
+	Variants            map[string]map[string]*Value `json:",omitempty"` // objects for each value of each discriminator, nil if there were too many
 }
 
-// Observe merges a into v.
+// DefaultMaxDistinctValues is the default number of distinct values tracked
+// for each Value.
+const DefaultMaxDistinctValues = 64
+
+// DefaultDiscriminators are the default properties whose values select the
+// variant of an object.
+var DefaultDiscriminators = []string{"type", "kind", "event", "eventType", "@type"}
+
+// DefaultMaxTupleLength is the default length up to which the elements of
+// arrays are tracked at each index.
+const DefaultMaxTupleLength = 8
+
+// An Observer holds the settings of an observation session. Goroutines can
+// share an Observer as long as they observe and merge different Values.
+type Observer struct {
+	// MaxDistinctValues is the number of distinct strings and integers tracked
+	// for each Value. Once a Value exceeds it, its distinct values are dropped
+	// and TooManyValues is set. Zero disables tracking.
+	MaxDistinctValues int
+
+	// Formats detects the semantic formats of strings. Nil disables
+	// detection.
+	Formats *FormatRegistry
+
+	// MaxTupleLength is the length up to which the elements of arrays are
+	// tracked at each index, so that they can be detected as tuples. Once a
+	// Value has a longer array, its ArrayIndexes are dropped. Zero disables
+	// tracking.
+	MaxTupleLength int
+
+	// Discriminators are the properties whose string values are tracked as
+	// the variants of objects, so that they can be detected as discriminated
+	// unions. At most MaxDistinctValues variants are tracked per property.
+	Discriminators []string
+
+	// TimestampFormats are the layouts of timestamps, in the format of
+	// time.Parse.
+	TimestampFormats []string
+
+	// WholeFloatsAsInts makes whole float64 numbers count as integers, as
+	// encoding/json decodes all numbers into float64 unless UseNumber is set.
+	WholeFloatsAsInts bool
+
+	// Progress, if not nil, is called by ObserveReader every
+	// ProgressInterval values and once it has read all values.
+	Progress func(Progress)
+}
+
+// NewObserver returns an Observer with the default settings.
+func NewObserver() *Observer {
+	return &Observer{
+		MaxDistinctValues: DefaultMaxDistinctValues,
+		Formats:           NewFormatRegistry(),
+		MaxTupleLength:    DefaultMaxTupleLength,
+		Discriminators:    DefaultDiscriminators,
+		WholeFloatsAsInts: true,
+		TimestampFormats:  append([]string(nil), TimestampFormats...),
+	}
+}
+
+// Observe merges a into v with the default settings.
 func (v *Value) Observe(a any) *Value {
+	return NewObserver().Observe(v, a)
+}
+
+// ObserveJSON observes the JSON document data with the default settings.
+func ObserveJSON(data []byte) (*Value, error) {
+	return NewObserver().ObserveJSON(nil, data)
+}
+
+// ObserveJSON merges the JSON document data into v. Numbers are decoded as
+// json.Number so that integers beyond float64's precision keep their value,
+// and objects are decoded token by token so that Value.PropertyOrder has the
+// order of their keys.
+func (o *Observer) ObserveJSON(v *Value, data []byte) (*Value, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	a, err := decodeValue(decoder)
+	if err != nil {
+		return v, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return v, fmt.Errorf("oojson: unexpected data after JSON document")
+	}
+	return o.Observe(v, a), nil
+}
+
+// Observe merges a into v.
+func (o *Observer) Observe(v *Value, a any) *Value {
 	if v == nil {
 		v = &Value{}
 	}
 	v.Observations++
 	switch a := a.(type) {
 	case []any:
+		o.observeIndexes(v, a)
 		v.Arrays++
 		if len(a) == 0 {
 			v.Emptys++
@@ -52,57 +174,399 @@ func (v *Value) Observe(a any) *Value {
 			v.ArrayElements = &Value{}
 		}
 		for _, e := range a {
-			v.ArrayElements = v.ArrayElements.Observe(e)
+			v.ArrayElements = o.Observe(v.ArrayElements, e)
 		}
 	case bool:
 		v.Bools++
 		if !a {
 			v.Emptys++
+			v.Zeros++
 		}
 	case float64:
+		if o.WholeFloatsAsInts && a == math.Trunc(a) && a >= math.MinInt64 && a < math.MaxInt64 {
+			o.countInt(v, int64(a))
+			break
+		}
+		observeNumber(v, a, false)
 		v.Float64s++
 		if a == 0 {
 			v.Emptys++
+			v.Zeros++
 		}
 	case int:
-		v.Ints++
-		if a == 0 {
-			v.Emptys++
-		}
+		o.countInt(v, int64(a))
 	case nil:
 		v.Nulls++
 	case map[string]any:
-		v.Objects++
-		if len(a) == 0 {
-			v.Emptys++
-		}
-		if v.ObjectProperties == nil {
-			v.ObjectProperties = make(map[string]*Value)
-		}
-		for property, value := range a {
-			v.AllObjectProperties = v.AllObjectProperties.Observe(value)
-			v.ObjectProperties[property] = v.ObjectProperties[property].Observe(value)
-		}
+		keys := maps.Keys(a)
+		sort.Strings(keys)
+		o.observeObject(v, a, keys)
+		o.observeVariants(v, a, keys)
+	case orderedObject:
+		o.observeObject(v, a.values, a.keys)
+		o.observeVariants(v, a.values, a.keys)
 	case string:
 		if a == "" {
 			v.Emptys++
+			v.Zeros++
 		}
-		if v.Times == v.Strings {
-			for _, f := range TimestampFormats {
-				if _, err := time.Parse(f, a); err == nil {
-					v.Times++
-					v.TimestampFormat = f
-					break
-				}
-			}
-		}
+		o.observeTimestamp(v, a)
+		o.observeFormats(v, a)
+		observeStringified(v, a)
 		v.Strings++
+		o.observeString(v, a)
 	case json.Number:
-		if _, err := a.Int64(); err == nil {
+		if i, err := a.Int64(); err == nil {
+			o.countInt(v, i)
+			break
+		}
+		f, _ := a.Float64()
+		isInt := !strings.ContainsAny(a.String(), ".eE")
+		observeNumber(v, f, isInt)
+		if isInt {
 			v.Ints++
+			if _, err := strconv.ParseUint(a.String(), 10, 64); err == nil {
+				v.Uint64s++
+			} else {
+				v.BigInts++
+			}
 		} else {
 			v.Float64s++
+			if f == 0 {
+				v.Emptys++
+				v.Zeros++
+			}
 		}
 	}
 	return v
 }
+
+// maxSafeInt is the largest integer up to which float64 represents all
+// integers exactly.
+const maxSafeInt = 1 << 53
+
+// observeNumber updates the range of the numbers observed for v. unsafeInt is
+// true if f is an integer that float64 cannot represent exactly. It must be
+// called before the number is counted.
+func observeNumber(v *Value, f float64, unsafeInt bool) {
+	if v.Ints+v.Float64s == 0 || f < v.MinNumber {
+		v.MinNumber = f
+	}
+	if v.Ints+v.Float64s == 0 || f > v.MaxNumber {
+		v.MaxNumber = f
+	}
+	if f < 0 {
+		v.Negatives++
+	}
+	if unsafeInt {
+		v.UnsafeInts++
+	}
+}
+
+// countInt counts the integer i.
+func (o *Observer) countInt(v *Value, i int64) {
+	observeNumber(v, float64(i), i > maxSafeInt || i < -maxSafeInt)
+	v.Ints++
+	if i == 0 {
+		v.Emptys++
+		v.Zeros++
+	}
+	observeUnixTime(v, i)
+	o.observeInt(v, i)
+}
+
+// observeObject counts the object a and observes its properties in the
+// order of keys.
+func (o *Observer) observeObject(v *Value, a map[string]any, keys []string) {
+	v.Objects++
+	if len(a) == 0 {
+		v.Emptys++
+	}
+	if v.ObjectProperties == nil {
+		v.ObjectProperties = make(map[string]*Value)
+	}
+	observePropertyOrder(v, keys)
+	for _, property := range keys {
+		value := a[property]
+		v.AllObjectProperties = o.Observe(v.AllObjectProperties, value)
+		v.ObjectProperties[property] = o.Observe(v.ObjectProperties[property], value)
+	}
+	v.countAbsents()
+}
+
+// countAbsents counts the objects of v that did not have each property.
+func (v *Value) countAbsents() {
+	for _, value := range v.ObjectProperties {
+		value.Absents = v.Objects - value.Observations
+	}
+}
+
+// observeVariants observes the object a as the variant selected by each of
+// its discriminator properties. Variants do not track variants themselves.
+func (o *Observer) observeVariants(v *Value, a map[string]any, keys []string) {
+	for _, property := range o.Discriminators {
+		value, ok := a[property].(string)
+		if !ok {
+			continue
+		}
+		variants, tracked := v.Variants[property]
+		if tracked && variants == nil {
+			continue
+		}
+		if v.Variants == nil {
+			v.Variants = make(map[string]map[string]*Value)
+		}
+		if variants[value] == nil && len(variants) >= o.MaxDistinctValues {
+			v.Variants[property] = nil
+			continue
+		}
+		if variants == nil {
+			variants = make(map[string]*Value)
+			v.Variants[property] = variants
+		}
+		if variants[value] == nil {
+			variants[value] = &Value{}
+		}
+		variants[value].Observations++
+		o.observeObject(variants[value], a, keys)
+	}
+}
+
+// observeIndexes updates the range of the array lengths observed for v and
+// observes the elements of a at each index. It must be called before a is
+// counted.
+func (o *Observer) observeIndexes(v *Value, a []any) {
+	if v.Arrays == 0 || len(a) < v.MinArrayLength {
+		v.MinArrayLength = len(a)
+	}
+	if v.Arrays == 0 || len(a) > v.MaxArrayLength {
+		v.MaxArrayLength = len(a)
+	}
+	if v.MaxArrayLength > o.MaxTupleLength {
+		v.ArrayIndexes = nil
+		return
+	}
+	for i, e := range a {
+		if i == len(v.ArrayIndexes) {
+			v.ArrayIndexes = append(v.ArrayIndexes, nil)
+		}
+		v.ArrayIndexes[i] = o.Observe(v.ArrayIndexes[i], e)
+	}
+}
+
+// observeFormats counts the formats that s matches. Only formats that all
+// previous strings matched are checked. It must be called before s is
+// counted.
+func (o *Observer) observeFormats(v *Value, s string) {
+	if o.Formats == nil {
+		return
+	}
+	for _, detector := range o.Formats.detectors {
+		if v.Formats[detector.Name] != v.Strings || !detector.Detect(s) {
+			continue
+		}
+		if v.Formats == nil {
+			v.Formats = make(map[string]int)
+		}
+		v.Formats[detector.Name]++
+	}
+}
+
+// observeString counts the distinct string s.
+func (o *Observer) observeString(v *Value, s string) {
+	if v.TooManyValues || o.MaxDistinctValues <= 0 {
+		return
+	}
+	if v.StringValues == nil {
+		v.StringValues = make(map[string]int)
+	}
+	v.StringValues[s]++
+	o.limitValues(v)
+}
+
+// observeInt counts the distinct integer i.
+func (o *Observer) observeInt(v *Value, i int64) {
+	if v.TooManyValues || o.MaxDistinctValues <= 0 {
+		return
+	}
+	if v.IntValues == nil {
+		v.IntValues = make(map[int64]int)
+	}
+	v.IntValues[i]++
+	o.limitValues(v)
+}
+
+// limitValues drops the distinct values of v if there are too many of them.
+func (o *Observer) limitValues(v *Value) {
+	if len(v.StringValues)+len(v.IntValues) > o.MaxDistinctValues {
+		v.StringValues = nil
+		v.IntValues = nil
+		v.TooManyValues = true
+	}
+}
+
+// Merge adds the observations of other to v with the default settings and
+// returns v, or a new Value if v is nil, like Observe.
+func (v *Value) Merge(other *Value) *Value {
+	return NewObserver().Merge(v, other)
+}
+
+// Merge adds the observations of other to v, as if the samples of other had
+// been observed by v, and returns v. The limits of o apply to the merged
+// Value.
+func (o *Observer) Merge(v, other *Value) *Value {
+	if v == nil {
+		v = &Value{}
+	}
+	if other != nil {
+		v.merge(other, o)
+	}
+	return v
+}
+
+// merge adds the observations in o to v. Subtrees that only o has are copied.
+// The limits of observer apply unless it is nil.
+func (v *Value) merge(o *Value, observer *Observer) {
+	if o.Ints+o.Float64s > 0 {
+		if v.Ints+v.Float64s == 0 || o.MinNumber < v.MinNumber {
+			v.MinNumber = o.MinNumber
+		}
+		if v.Ints+v.Float64s == 0 || o.MaxNumber > v.MaxNumber {
+			v.MaxNumber = o.MaxNumber
+		}
+	}
+	if o.StringInts > 0 {
+		if v.StringInts == 0 || o.MinStringInt < v.MinStringInt {
+			v.MinStringInt = o.MinStringInt
+		}
+		if v.StringInts == 0 || o.MaxStringInt > v.MaxStringInt {
+			v.MaxStringInt = o.MaxStringInt
+		}
+	}
+	if len(v.ArrayIndexes) == v.MaxArrayLength && len(o.ArrayIndexes) == o.MaxArrayLength {
+		for i, index := range o.ArrayIndexes {
+			if i == len(v.ArrayIndexes) {
+				v.ArrayIndexes = append(v.ArrayIndexes, &Value{})
+			}
+			v.ArrayIndexes[i].merge(index, observer)
+		}
+	} else {
+		v.ArrayIndexes = nil
+	}
+	if o.Arrays > 0 {
+		if v.Arrays == 0 || o.MinArrayLength < v.MinArrayLength {
+			v.MinArrayLength = o.MinArrayLength
+		}
+		if v.Arrays == 0 || o.MaxArrayLength > v.MaxArrayLength {
+			v.MaxArrayLength = o.MaxArrayLength
+		}
+		if observer != nil && v.MaxArrayLength > observer.MaxTupleLength {
+			v.ArrayIndexes = nil
+		}
+	}
+	v.Negatives += o.Negatives
+	v.UnsafeInts += o.UnsafeInts
+	v.Uint64s += o.Uint64s
+	v.BigInts += o.BigInts
+	v.Observations += o.Observations
+	v.Emptys += o.Emptys
+	v.Zeros += o.Zeros
+	v.Arrays += o.Arrays
+	v.Bools += o.Bools
+	v.Float64s += o.Float64s
+	v.Ints += o.Ints
+	v.Nulls += o.Nulls
+	v.Objects += o.Objects
+	v.Strings += o.Strings
+	v.Times += o.Times
+	v.StringInts += o.StringInts
+	v.StringFloats += o.StringFloats
+	v.StringBools += o.StringBools
+	v.StringZeros += o.StringZeros
+	if v.TimestampFormat == "" {
+		v.TimestampFormat = o.TimestampFormat
+	}
+	for format, n := range o.TimestampFormats {
+		if v.TimestampFormats == nil {
+			v.TimestampFormats = make(map[string]int)
+		}
+		v.TimestampFormats[format] += n
+	}
+	v.UnixSeconds += o.UnixSeconds
+	v.UnixMillis += o.UnixMillis
+	for format, n := range o.Formats {
+		if v.Formats == nil {
+			v.Formats = make(map[string]int)
+		}
+		v.Formats[format] += n
+	}
+	if o.TooManyValues {
+		v.TooManyValues = true
+	}
+	if v.TooManyValues {
+		v.StringValues = nil
+		v.IntValues = nil
+	} else {
+		for s, n := range o.StringValues {
+			if v.StringValues == nil {
+				v.StringValues = make(map[string]int)
+			}
+			v.StringValues[s] += n
+		}
+		for i, n := range o.IntValues {
+			if v.IntValues == nil {
+				v.IntValues = make(map[int64]int)
+			}
+			v.IntValues[i] += n
+		}
+		if observer != nil {
+			observer.limitValues(v)
+		}
+	}
+	if o.ArrayElements != nil {
+		if v.ArrayElements == nil {
+			v.ArrayElements = &Value{}
+		}
+		v.ArrayElements.merge(o.ArrayElements, observer)
+	}
+	if o.AllObjectProperties != nil {
+		if v.AllObjectProperties == nil {
+			v.AllObjectProperties = &Value{}
+		}
+		v.AllObjectProperties.merge(o.AllObjectProperties, observer)
+	}
+	for property, variants := range o.Variants {
+		if v.Variants == nil {
+			v.Variants = make(map[string]map[string]*Value)
+		}
+		if current, tracked := v.Variants[property]; tracked && current == nil || variants == nil {
+			v.Variants[property] = nil
+			continue
+		}
+		if v.Variants[property] == nil {
+			v.Variants[property] = make(map[string]*Value)
+		}
+		for value, variant := range variants {
+			if v.Variants[property][value] == nil {
+				v.Variants[property][value] = &Value{}
+			}
+			v.Variants[property][value].merge(variant, observer)
+		}
+		if observer != nil && len(v.Variants[property]) > observer.MaxDistinctValues {
+			v.Variants[property] = nil
+		}
+	}
+	if o.ObjectProperties != nil {
+		if v.ObjectProperties == nil {
+			v.ObjectProperties = make(map[string]*Value)
+		}
+		observePropertyOrder(v, o.PropertyOrder)
+		for property, value := range o.ObjectProperties {
+			if v.ObjectProperties[property] == nil {
+				v.ObjectProperties[property] = &Value{}
+			}
+			v.ObjectProperties[property].merge(value, observer)
+		}
+	}
+	v.countAbsents()
+}
@@ -0,0 +1,30 @@
+package oojson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMergeNilValue(t *testing.T) {
+	other := observeJSON(t, `{"a":1}`)
+	var v *Value
+	v = v.Merge(other)
+	if v == nil || v.Objects != 1 || v.ObjectProperties["a"].Ints != 1 {
+		t.Errorf("expected the observations of other, got %+v", v)
+	}
+}
+
+func TestObserveDecodersAgree(t *testing.T) {
+	data := `{"a":[0,1,1.5,-3,9007199254740993]}`
+	numbers := observeJSON(t, data)
+	var a any
+	if err := json.Unmarshal([]byte(data), &a); err != nil {
+		t.Fatal(err)
+	}
+	floats := NewObserver().Observe(nil, a)
+	n, f := numbers.ObjectProperties["a"].ArrayElements, floats.ObjectProperties["a"].ArrayElements
+	// float64 rounds the unsafe integer, so only the counters are compared.
+	if n.Ints != f.Ints || n.Float64s != f.Float64s || n.Emptys != f.Emptys || n.Zeros != f.Zeros || n.Negatives != f.Negatives {
+		t.Errorf("expected the same counts from json.Number and float64, got %+v and %+v", n, f)
+	}
+}